@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"al.essio.dev/pkg/tools/dirlist"
+)
+
+// allowedFilename is the name of the file that maps the absolute
+// path of each trusted batch file to the SHA-256 hash of its
+// contents at the time it was allowed.
+const allowedFilename = "allowed.json"
+
+// queryHandlerAllow trusts the current contents of a batch file,
+// so that apply -allowed, which the shell hook runs, accepts it.
+func (c *pathctl) queryHandlerAllow(_ dirlist.List, args []string) (int, error) {
+	filename, err := allowArg("allow", args)
+	if err != nil {
+		return 1, err
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return 1, err
+	}
+
+	err = updateAllowed(func(allowed map[string]string) {
+		allowed[filename] = hash(data)
+	})
+	if err != nil {
+		return 1, fmt.Errorf("couldn't allow %s: %w", filename, err)
+	}
+
+	c.verbosef("allowed %s", filename)
+
+	return 0, nil
+}
+
+// queryHandlerDeny revokes the trust granted by the allow command.
+func (c *pathctl) queryHandlerDeny(_ dirlist.List, args []string) (int, error) {
+	filename, err := allowArg("deny", args)
+	if err != nil {
+		return 1, err
+	}
+
+	err = updateAllowed(func(allowed map[string]string) {
+		delete(allowed, filename)
+	})
+	if err != nil {
+		return 1, fmt.Errorf("couldn't deny %s: %w", filename, err)
+	}
+
+	c.verbosef("denied %s", filename)
+
+	return 0, nil
+}
+
+// allowArg returns the absolute path of the file given to cmd,
+// which defaults to the hookFilename file in the current directory.
+func allowArg(cmd string, args []string) (string, error) {
+	switch len(args) {
+	case 0:
+		return filepath.Abs(hookFilename)
+	case 1:
+		return filepath.Abs(args[0])
+	}
+
+	return "", usagef("usage: %s %s [FILE]", program, cmd)
+}
+
+// checkAllowed returns an error unless data is the content
+// that filename had when it was allowed.
+func checkAllowed(filename string, data []byte) error {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+
+	allowedFile, err := configFile(allowedFilename)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := readAllowed(allowedFile)
+	if err != nil {
+		return err
+	}
+
+	switch sum, ok := allowed[abs]; {
+	case !ok:
+		return fmt.Errorf("%s is not allowed, run '%s allow %s' to trust it", abs, program, abs)
+	case sum != hash(data):
+		return fmt.Errorf("%s changed since it was allowed, run '%s allow %s' to trust it", abs, program, abs)
+	}
+
+	return nil
+}
+
+// updateAllowed calls update on the allowed files, and saves
+// the result while holding the lock on the allowedFilename file.
+func updateAllowed(update func(map[string]string)) error {
+	filename, err := configFile(allowedFilename)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(filename)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	allowed, err := readAllowed(filename)
+	if err != nil {
+		return err
+	}
+
+	update(allowed)
+
+	data, err := json.MarshalIndent(allowed, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filename, data)
+}
+
+func readAllowed(filename string) (map[string]string, error) {
+	allowed := make(map[string]string)
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return allowed, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &allowed); err != nil {
+		return nil, fmt.Errorf("couldn't decode %s: %w", filename, err)
+	} else if allowed == nil {
+		allowed = make(map[string]string)
+	}
+
+	return allowed, nil
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
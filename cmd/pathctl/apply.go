@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"al.essio.dev/pkg/tools/dirlist"
+)
+
+// operation is a single step of a batch run by the apply command.
+type operation struct {
+	Var  string   `json:"var"`
+	Op   string   `json:"op"`
+	Args []string `json:"args"`
+}
+
+// varName matches the variable names that apply accepts, which are
+// evaluated by the shell as part of the export and unset statements.
+var varName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// queryHandlerApply runs a batch of operations on multiple environment
+// variables and prints the resulting export statements. Variables are
+// printed in the order they first appear in the batch. Relative paths
+// in a batch read from a file are relative to the file's directory.
+//
+// With -restore, it prints instead the statements that restore the
+// current values of the variables the batch would modify. With
+// -allowed, it refuses to run a batch file unless its current
+// contents have been trusted with the allow command.
+//
+// As the output is meant to be evaluated by the shell, the paths
+// are quoted at least as -quote posix would do.
+func (c *pathctl) queryHandlerApply(_ dirlist.List, args []string) (int, error) {
+	c.quoting = max(c.quoting, dirlist.QuotePOSIX)
+
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	restore := fs.Bool("restore", false, "")
+	allowed := fs.Bool("allowed", false, "")
+
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return 1, usagef("usage: %s apply [-restore] [-allowed] FILE", program)
+	}
+
+	args = fs.Args()
+
+	ops, err := c.readOperations(args[0], *allowed)
+	if err != nil {
+		return 1, fmt.Errorf("couldn't read operations: %w", err)
+	}
+
+	var (
+		names []string
+		lists = make(map[string]dirlist.List)
+	)
+
+	for i, op := range ops {
+		handler, ok := cmdHandlers[op.Op]
+		if !ok {
+			return 1, fmt.Errorf("operation %d: unrecognized command: %s", i, op.Op)
+		}
+
+		d, ok := lists[op.Var]
+		if !ok {
+			d = c.newDirList()
+			d.LoadEnv(op.Var)
+			lists[op.Var] = d
+			names = append(names, op.Var)
+		}
+
+		if err := handler(c, d, op.Args); err != nil {
+			return 1, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	if *restore {
+		c.printRestore(names)
+		return 0, nil
+	}
+
+	for _, name := range names {
+		d := lists[name]
+		_, _ = fmt.Fprintf(c.stdout, "export %s=%s\n", name, d.String())
+
+		if c.recordMode {
+			c.recordHistory(name, d, append([]string{"apply"}, args...))
+		}
+	}
+
+	return 0, nil
+}
+
+// readOperations reads the batch of operations in filename, or in
+// the standard input if filename is -. If allowed is true, the file
+// must have been trusted with the allow command.
+func (c *pathctl) readOperations(filename string, allowed bool) ([]operation, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	switch {
+	case filename != "-":
+		data, err = os.ReadFile(filename)
+	case c.readFromStdin():
+		return nil, fmt.Errorf("standard input is already used to read the list")
+	case allowed:
+		return nil, fmt.Errorf("the operations read from standard input cannot be allowed")
+	default:
+		data, err = io.ReadAll(c.stdin)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the same data that is going to be
+	// applied, in case the file changes meanwhile.
+	if allowed {
+		if err := checkAllowed(filename, data); err != nil {
+			return nil, err
+		}
+	}
+
+	var ops []operation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		switch {
+		case op.Var == "":
+			return nil, fmt.Errorf("operation %d: missing variable name", i)
+		case !varName.MatchString(op.Var):
+			return nil, fmt.Errorf("operation %d: invalid variable name: %q", i, op.Var)
+		}
+	}
+
+	if filename == "-" {
+		return ops, nil
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ops {
+		c.resolveArgs(dir, &ops[i])
+	}
+
+	return ops, nil
+}
+
+// resolveArgs makes the relative paths among the arguments
+// of op relative to dir rather than to the current directory.
+func (c *pathctl) resolveArgs(dir string, op *operation) {
+	from, to := 0, len(op.Args)
+
+	switch op.Op {
+	case "drop", "d":
+		if to != 0 && slices.Contains([]string{"-glob", "--glob", "-prefix", "--prefix"}, op.Args[0]) {
+			from = 1
+		}
+	case "insert", "i":
+		// Skip either INDEX, or -before and -after.
+		from = 1
+	case "move", "m":
+		// Skip POSITION.
+		to = min(to, 1)
+	case "prune":
+		to = 0
+	}
+
+	for i := from; i < to; i++ {
+		if p := op.Args[i]; !c.isAbs(p) {
+			op.Args[i] = filepath.Join(dir, p)
+		}
+	}
+}
+
+func (c *pathctl) isAbs(p string) bool {
+	if c.windowsMode {
+		p = dirlist.CleanWindowsPath(p)
+		return strings.HasPrefix(p, `\`) || (len(p) >= 2 && p[1] == ':')
+	}
+
+	return filepath.IsAbs(p)
+}
+
+// printRestore prints the statements that set the variables
+// back to their current values, or unset them if unset.
+func (c *pathctl) printRestore(names []string) {
+	for _, name := range names {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			_, _ = fmt.Fprintf(c.stdout, "unset %s\n", name)
+			continue
+		}
+
+		_, _ = fmt.Fprintf(c.stdout, "export %s=%s\n", name, dirlist.QuotePOSIX.Quote(value))
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"al.essio.dev/pkg/tools/dirlist"
+)
+
+// queryHandlerDoctor inspects both the raw value of the environment
+// variable and the cleaned list, and reports any problem found.
+func (c *pathctl) queryHandlerDoctor(d dirlist.List, _ []string) (int, error) {
+	var problems int
+
+	report := func(format string, v ...any) {
+		problems++
+		_, _ = fmt.Fprintf(c.stdout, format+"\n", v...)
+	}
+
+	quote := dirlist.QuotePOSIX.Quote
+
+	fix := fmt.Sprintf(`export "$(%s -E %s)"`, program, quote(c.envVar))
+	if c.readFromStdin() {
+		fix = "remove the entry"
+	}
+
+	// drop returns the command that drops p from the list.
+	drop := func(p string) string {
+		if c.readFromStdin() {
+			return fmt.Sprintf("%s -stdin drop %s", program, quote(p))
+		}
+
+		return fmt.Sprintf(`export "$(%s -E %s drop %s)"`, program, quote(c.envVar), quote(p))
+	}
+
+	seen := make(map[string]struct{})
+
+	for _, p := range c.rawEntries {
+		if strings.TrimSpace(p) == "" {
+			report("empty entry: the current directory is searched (fix: %s)", fix)
+			continue
+		}
+
+		cp := c.pathKey(c.cleanPath(p))
+		if _, ok := seen[cp]; ok {
+			report("duplicate: %s (fix: %s)", p, fix)
+		}
+
+		seen[cp] = struct{}{}
+	}
+
+	var names []string
+
+	dirs := make(map[string]os.FileInfo)
+	seenNames := make(map[string]struct{})
+
+	for _, p := range d.Slice() {
+		if !filepath.IsAbs(p) {
+			report("relative path: %s (fix: use an absolute path)", p)
+		}
+
+		fi, err := os.Stat(p)
+
+		switch {
+		case err != nil:
+			report("nonexistent: %s (fix: %s)", p, drop(p))
+			continue
+		case !fi.IsDir():
+			report("not a directory: %s (fix: %s)", p, drop(p))
+			continue
+		case fi.Mode().Perm()&0o002 != 0 && fi.Mode()&os.ModeSticky == 0:
+			report("world-writable: %s (fix: chmod o-w %s)", p, quote(p))
+		}
+
+		if alias := sameDir(dirs, fi); alias != "" {
+			report("alias: %s is the same directory as %s (fix: %s)", p, alias, drop(p))
+			continue
+		}
+
+		dirs[p] = fi
+
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			report("unreadable: %s: %v", p, err)
+			continue
+		}
+
+		for _, e := range entries {
+			name := e.Name()
+			if runtime.GOOS == "windows" {
+				// Let Which try the extensions in PATHEXT.
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+
+			if _, ok := seenNames[name]; !ok {
+				seenNames[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		found, err := d.Which(name)
+		if err != nil {
+			continue
+		}
+
+		found = distinctFiles(found)
+		if len(found) < 2 {
+			continue
+		}
+
+		for _, p := range found[1:] {
+			report("shadowed: %s is shadowed by %s", p, found[0])
+		}
+	}
+
+	if problems != 0 {
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// distinctFiles returns the paths in files, omitting those
+// that are the same file as one of the paths before them.
+func distinctFiles(files []string) []string {
+	var (
+		distinct []string
+		infos    []os.FileInfo
+	)
+
+	for _, p := range files {
+		fi, err := os.Stat(p)
+		if err != nil || slices.ContainsFunc(infos, func(other os.FileInfo) bool { return os.SameFile(fi, other) }) {
+			continue
+		}
+
+		distinct = append(distinct, p)
+		infos = append(infos, fi)
+	}
+
+	return distinct
+}
+
+// sameDir returns the path of a directory in dirs that
+// is the same file as fi, or "" if there is none.
+func sameDir(dirs map[string]os.FileInfo, fi os.FileInfo) string {
+	for p, other := range dirs {
+		if os.SameFile(fi, other) {
+			return p
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"al.essio.dev/pkg/tools/dirlist"
+)
+
+const editHelp = `Commands, one per line (PATH extends to the end of the line):
+  a PATH      append PATH
+  p PATH      prepend PATH
+  i N PATH    insert PATH at position N
+  d N         drop the path at position N
+  m N M       move the path at position N to position M
+  u N         move the path at position N up
+  n N         move the path at position N down
+  s N M       swap the paths at positions N and M
+  w           print the list and exit
+  x           exit without printing the list
+  ?           display this help`
+
+// queryHandlerEdit runs an interactive editor that reads commands from
+// standard input. The editor writes to standard error, so that the
+// resulting list printed on exit can be passed to the shell's eval.
+// For the same reason, the paths are quoted at least as -quote posix
+// would do.
+func (c *pathctl) queryHandlerEdit(_ dirlist.List, _ []string) (int, error) {
+	if c.readFromStdin() {
+		return 1, fmt.Errorf("the edit command cannot read the list from standard input")
+	}
+
+	c.quoting = max(c.quoting, dirlist.QuotePOSIX)
+
+	d := c.newDirList()
+	d.LoadEnv(c.envVar)
+
+	before := d.Clone()
+
+	if !c.editLoop(d) {
+		return 1, nil
+	}
+
+	if !before.Equal(d) && c.recordMode {
+		c.recordHistory(c.envVar, d, []string{"edit"})
+	}
+
+	c.printPathList(d)
+
+	return 0, nil
+}
+
+// editLoop applies the commands read from the standard input to d
+// until either w, x, or EOF is read, and writes the prompts to the
+// standard error. It returns false if the user aborted.
+func (c *pathctl) editLoop(d dirlist.List) bool {
+	w := c.stderr
+	scanner := bufio.NewScanner(c.stdin)
+
+	for {
+		c.printEditList(d)
+		_, _ = fmt.Fprint(w, "> ")
+
+		if !scanner.Scan() {
+			_, _ = fmt.Fprintln(w)
+			return true
+		}
+
+		cmd, rest := splitField(scanner.Text())
+		if cmd == "" {
+			continue
+		}
+
+		args := strings.Fields(rest)
+
+		// Paths may contain spaces, hence they
+		// extend to the end of the line.
+		switch cmd {
+		case "a", "p":
+			if rest != "" {
+				args = []string{rest}
+			}
+		case "i":
+			if n, p := splitField(rest); p != "" {
+				args = []string{n, p}
+			}
+		}
+
+		switch cmd {
+		case "w":
+			return true
+		case "x":
+			return false
+		case "?":
+			_, _ = fmt.Fprintln(w, editHelp)
+		default:
+			if err := editCommand(d, cmd, args); err != nil {
+				_, _ = fmt.Fprintf(w, "error: %v\n", err)
+			}
+		}
+	}
+}
+
+// splitField returns the first whitespace-separated field
+// of s and the rest of s, trimmed of the surrounding spaces.
+func splitField(s string) (string, string) {
+	s = strings.TrimSpace(s)
+
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i == -1 {
+		return s, ""
+	}
+
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+func editCommand(d dirlist.List, cmd string, args []string) error {
+	lst := d.Slice()
+
+	// Convert the positions to paths.
+	at := func(s string) (string, error) {
+		i, err := strconv.Atoi(s)
+		if err != nil || i < 0 || i >= len(lst) {
+			return "", fmt.Errorf("invalid position: %s", s)
+		}
+
+		return lst[i], nil
+	}
+
+	wantArgs := map[string]int{"a": 1, "p": 1, "i": 2, "d": 1, "m": 2, "u": 1, "n": 1, "s": 2}
+
+	n, ok := wantArgs[cmd]
+	if !ok {
+		return fmt.Errorf("unrecognized command: %s (type ? for help)", cmd)
+	}
+
+	if len(args) != n {
+		return fmt.Errorf("%s: want %d arguments, got %d", cmd, n, len(args))
+	}
+
+	switch cmd {
+	case "a":
+		d.Append(args[0])
+	case "p":
+		d.Prepend(args[0])
+	case "i":
+		i, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid position: %s", args[0])
+		}
+
+		return d.InsertAt(i, args[1])
+	case "d":
+		p, err := at(args[0])
+		if err != nil {
+			return err
+		}
+
+		d.Drop(p)
+	case "m", "u", "n":
+		p, err := at(args[0])
+		if err != nil {
+			return err
+		}
+
+		pos := map[string]string{"u": "up", "n": "down"}[cmd]
+		if cmd == "m" {
+			pos = args[1]
+		}
+
+		return movePath(d, p, pos)
+	case "s":
+		p1, err := at(args[0])
+		if err != nil {
+			return err
+		}
+
+		p2, err := at(args[1])
+		if err != nil {
+			return err
+		}
+
+		return swapPaths(d, p1, p2)
+	}
+
+	return nil
+}
+
+func (c *pathctl) printEditList(d dirlist.List) {
+	w := c.stderr
+	_, _ = fmt.Fprintf(w, "\n%s:\n", c.envVar)
+
+	for i, p := range d.Slice() {
+		mark := " "
+		if fi, err := os.Stat(p); err != nil || !fi.IsDir() {
+			mark = "!"
+		}
+
+		_, _ = fmt.Fprintf(w, "%s %3d  %s\n", mark, i, p)
+	}
+
+	_, _ = fmt.Fprintln(w, "\n'!' marks the paths that are not existing directories; type ? for help.")
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"al.essio.dev/pkg/tools/dirlist"
+)
+
+const (
+	configDirname   = "pathctl"
+	historyFilename = "history.json"
+	historyMaxLen   = 100
+
+	lockTimeout = 5 * time.Second
+)
+
+// historyEntry records a change made to an environment variable.
+type historyEntry struct {
+	Time    time.Time `json:"time"`
+	Var     string    `json:"var"`
+	Command []string  `json:"command"`
+	Before  string    `json:"before"`
+	After   string    `json:"after"`
+}
+
+// recordHistory appends the change made to the variable name by
+// command to the history file, unless d holds its current value.
+// Failures are reported but are not fatal, as the change itself
+// succeeded.
+func (c *pathctl) recordHistory(name string, d dirlist.List, command []string) {
+	if c.readFromStdin() {
+		return
+	}
+
+	// Record the value the shell will hold, that is without quoting.
+	before, after := os.Getenv(name), strings.Join(d.Slice(), string(c.listSeparator()))
+	if before == after {
+		return
+	}
+
+	err := updateHistory(func(entries []historyEntry) ([]historyEntry, error) {
+		entries = append(entries, historyEntry{
+			Time:    time.Now(),
+			Var:     name,
+			Command: command,
+			Before:  before,
+			After:   after,
+		})
+
+		if len(entries) > historyMaxLen {
+			entries = entries[len(entries)-historyMaxLen:]
+		}
+
+		return entries, nil
+	})
+	if err != nil {
+		c.logf("couldn't record history: %v", err)
+	}
+}
+
+// queryHandlerHistory prints the changes recorded for the
+// variable, from the least to the most recent.
+func (c *pathctl) queryHandlerHistory(_ dirlist.List, _ []string) (int, error) {
+	entries, err := loadHistory()
+	if err != nil {
+		return 1, fmt.Errorf("couldn't load history: %w", err)
+	}
+
+	for i, e := range entries {
+		if e.Var != c.envVar {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(c.stdout, "%d\t%s\t%s %s\n", i, e.Time.Format(time.DateTime), program, strings.Join(e.Command, " "))
+	}
+
+	return 0, nil
+}
+
+// queryHandlerUndo prints the value the variable had before the
+// most recent change that led to its current value. The history
+// is left untouched, so that the output can be merely previewed,
+// and running undo again after evaluating it reverts the change
+// before. As the output is meant to be evaluated by the shell,
+// the paths are quoted at least as -quote posix would do.
+func (c *pathctl) queryHandlerUndo(_ dirlist.List, _ []string) (int, error) {
+	c.quoting = max(c.quoting, dirlist.QuotePOSIX)
+
+	entries, err := loadHistory()
+	if err != nil {
+		return 1, fmt.Errorf("couldn't load history: %w", err)
+	}
+
+	cur := os.Getenv(c.envVar)
+
+	i := len(entries) - 1
+	for i >= 0 && (entries[i].Var != c.envVar || entries[i].After != cur) {
+		i--
+	}
+
+	if i == -1 {
+		return 1, fmt.Errorf("no changes to %s to undo", c.envVar)
+	}
+
+	d := c.newDirList()
+	d.Load(entries[i].Before)
+	c.printPathList(d)
+
+	return 0, nil
+}
+
+func historyFile() (string, error) {
+	return configFile(historyFilename)
+}
+
+// configFile returns the path of the file with the given
+// name in the user's configuration directory.
+func configFile(name string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, configDirname, name), nil
+}
+
+func loadHistory() ([]historyEntry, error) {
+	filename, err := historyFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return readHistory(filename)
+}
+
+// updateHistory replaces the entries in the history file with those
+// returned by update. The file is locked for the whole operation, so
+// that concurrent runs do not lose each other's changes, and replaced
+// atomically, so that readers never see it partially written.
+func updateHistory(update func([]historyEntry) ([]historyEntry, error)) error {
+	filename, err := historyFile()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(filename)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	entries, err := readHistory(filename)
+	if err != nil {
+		return fmt.Errorf("couldn't load history: %w", err)
+	}
+
+	if entries, err = update(entries); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filename, data)
+}
+
+func readHistory(filename string) ([]historyEntry, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []historyEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("couldn't decode %s: %w", filename, err)
+	}
+
+	return entries, nil
+}
+
+// lockFile acquires an advisory lock on filename by creating a lock
+// file next to it, and returns the function that releases the lock.
+// Lock files older than lockTimeout are assumed to be left over by a
+// process that died, and are removed.
+func lockFile(filename string) (func(), error) {
+	lock := filename + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lock) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if fi, err := os.Stat(lock); err == nil && time.Since(fi.ModTime()) > lockTimeout {
+			_ = os.Remove(lock)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("couldn't lock %s: %s exists", filename, lock)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file in the
+// same directory as filename, then renames it to filename.
+func writeFileAtomic(filename string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	if err := os.Rename(f.Name(), filename); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+
+	"al.essio.dev/pkg/tools/dirlist"
+)
+
+// hookFilename is the name of the per-directory
+// file of operations that the shell hook applies.
+const hookFilename = ".pathctl"
+
+// The hooks apply the operations in the hookFilename file found in
+// the current directory, if it has been allowed, and restore the
+// variables when the shell leaves it. Neither step is recorded in
+// the history. Until the file is applied, the hooks retry quietly
+// at each prompt, so that it takes effect as soon as it is allowed.
+var shellHooks = map[string]string{
+	"bash": `_pathctl_hook() {
+  if [ "$PWD" != "${_PATHCTL_DIR-}" ]; then
+    _PATHCTL_DIR=$PWD
+    unset _PATHCTL_PENDING
+    if [ -n "${_PATHCTL_RESTORE-}" ]; then
+      eval "$_PATHCTL_RESTORE"
+      unset _PATHCTL_RESTORE
+    fi
+    [ -f %[2]s ] && _pathctl_apply
+  elif [ -n "${_PATHCTL_PENDING-}" ] && [ -f %[2]s ]; then
+    _pathctl_apply 2>/dev/null
+  fi
+}
+_pathctl_apply() {
+  local restore ops
+  _PATHCTL_PENDING=1
+  restore=$(%[1]s apply -allowed -restore %[2]s) &&
+    ops=$(%[1]s -record=false apply -allowed %[2]s) || return
+  _PATHCTL_RESTORE=$restore
+  eval "$ops"
+  unset _PATHCTL_PENDING
+}
+case ";${PROMPT_COMMAND-};" in
+  *";_pathctl_hook;"*) ;;
+  *) PROMPT_COMMAND="_pathctl_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+`,
+	"zsh": `_pathctl_hook() {
+  if [ "$PWD" != "${_PATHCTL_DIR-}" ]; then
+    _PATHCTL_DIR=$PWD
+    unset _PATHCTL_PENDING
+    if [ -n "${_PATHCTL_RESTORE-}" ]; then
+      eval "$_PATHCTL_RESTORE"
+      unset _PATHCTL_RESTORE
+    fi
+    [ -f %[2]s ] && _pathctl_apply
+  elif [ -n "${_PATHCTL_PENDING-}" ] && [ -f %[2]s ]; then
+    _pathctl_apply 2>/dev/null
+  fi
+}
+_pathctl_apply() {
+  local restore ops
+  _PATHCTL_PENDING=1
+  restore=$(%[1]s apply -allowed -restore %[2]s) &&
+    ops=$(%[1]s -record=false apply -allowed %[2]s) || return
+  _PATHCTL_RESTORE=$restore
+  eval "$ops"
+  unset _PATHCTL_PENDING
+}
+autoload -U add-zsh-hook
+add-zsh-hook chpwd _pathctl_hook
+add-zsh-hook precmd _pathctl_hook
+_pathctl_hook
+`,
+	"fish": `function _pathctl_hook --on-variable PWD --on-event fish_prompt
+    if test "$PWD" != "$_pathctl_dir"
+        set -g _pathctl_dir $PWD
+        set -e _pathctl_pending
+        if set -q _pathctl_restore
+            printf '%%s\n' $_pathctl_restore | string replace -r '^unset ' 'set -e ' | source
+            set -e _pathctl_restore
+        end
+        test -f %[2]s
+        and _pathctl_apply
+    else if set -q _pathctl_pending; and test -f %[2]s
+        _pathctl_apply 2>/dev/null
+    end
+end
+function _pathctl_apply
+    set -g _pathctl_pending 1
+    set -l restore (%[1]s apply -allowed -restore %[2]s)
+    or return
+    set -l ops (%[1]s -record=false apply -allowed %[2]s)
+    or return
+    set -g _pathctl_restore $restore
+    printf '%%s\n' $ops | source
+    set -e _pathctl_pending
+end
+_pathctl_hook
+`,
+}
+
+// queryHandlerHook prints the hook for the given shell.
+func (c *pathctl) queryHandlerHook(_ dirlist.List, args []string) (int, error) {
+	if len(args) != 1 {
+		return 1, usagef("usage: %s hook bash|fish|zsh", program)
+	}
+
+	hook, ok := shellHooks[args[0]]
+	if !ok {
+		return 1, fmt.Errorf("unsupported shell: %s", args[0])
+	}
+
+	_, _ = fmt.Fprintf(c.stdout, hook, program, hookFilename)
+
+	return 0, nil
+}
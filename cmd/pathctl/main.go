@@ -1,11 +1,17 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"al.essio.dev/pkg/tools/dirlist"
 	"al.essio.dev/pkg/tools/internal/version"
@@ -15,144 +21,712 @@ const (
 	program = "pathctl"
 )
 
-var (
+// pathctl holds the command line options and
+// the streams that the commands use.
+type pathctl struct {
 	helpMode     bool
 	versionMode  bool
 	listMode     bool
 	noPrefixMode bool
 	dropMode     bool
-)
+	verboseMode  bool
+	stdinMode    bool
+	windowsMode  bool
+	recordMode   bool
+
+	envVar    string
+	separator rune
+	quoting   dirlist.Quoting
+
+	// rawEntries holds the input entries as they
+	// were read, before any cleaning took place.
+	rawEntries []string
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// A cmdHandler modifies the list, which is printed afterwards.
+type cmdHandler func(c *pathctl, d dirlist.List, args []string) error
+
+// A queryHandler does its own output and returns the exit status.
+type queryHandler func(c *pathctl, d dirlist.List, args []string) (int, error)
 
 var (
-	envVar string
+	cmdHandlers   map[string]cmdHandler
+	queryHandlers map[string]queryHandler
 )
 
-var cmdHandlers map[string]func(d dirlist.List)
-
 func init() {
-	flag.BoolVar(&helpMode, "help", false, "display this help and exit.")
-	flag.BoolVar(&versionMode, "version", false, "output version information and exit.")
-	flag.BoolVar(&dropMode, "D", false, "drop the path before adding it again to the list.")
-	flag.BoolVar(&noPrefixMode, "noprefix", false, "output the variable contents only.")
-	flag.BoolVar(&listMode, "L", false, "use a newline character as path list separator.")
-	flag.StringVar(&envVar, "E", "PATH", "input environment variable.")
-	flag.Usage = usage
-	flag.CommandLine.SetOutput(os.Stderr)
+	cmdHandlers = map[string]cmdHandler{
+		"append":  (*pathctl).cmdHandlerAppend,
+		"drop":    (*pathctl).cmdHandlerDrop,
+		"insert":  (*pathctl).cmdHandlerInsert,
+		"move":    (*pathctl).cmdHandlerMove,
+		"prepend": (*pathctl).cmdHandlerPrepend,
+		"prune":   (*pathctl).cmdHandlerPrune,
+		"swap":    (*pathctl).cmdHandlerSwap,
 
-	cmdHandlers = func() map[string]func(dirlist.List) {
-		return map[string]func(dirlist.List){
-			"append":  cmdHandlerAppend,
-			"drop":    cmdHandlerDrop,
-			"prepend": cmdHandlerPrepend,
+		// aliases
+		"a": (*pathctl).cmdHandlerAppend,
+		"d": (*pathctl).cmdHandlerDrop,
+		"i": (*pathctl).cmdHandlerInsert,
+		"m": (*pathctl).cmdHandlerMove,
+		"p": (*pathctl).cmdHandlerPrepend,
+	}
 
-			// aliases
-			"a": cmdHandlerAppend,
-			"d": cmdHandlerDrop,
-			"p": cmdHandlerPrepend,
-		}
-	}()
+	queryHandlers = map[string]queryHandler{
+		"allow":   (*pathctl).queryHandlerAllow,
+		"apply":   (*pathctl).queryHandlerApply,
+		"deny":    (*pathctl).queryHandlerDeny,
+		"doctor":  (*pathctl).queryHandlerDoctor,
+		"edit":    (*pathctl).queryHandlerEdit,
+		"has":     (*pathctl).queryHandlerHas,
+		"history": (*pathctl).queryHandlerHistory,
+		"hook":    (*pathctl).queryHandlerHook,
+		"undo":    (*pathctl).queryHandlerUndo,
+		"which":   (*pathctl).queryHandlerWhich,
+	}
 }
 
 func main() {
-	log.SetFlags(0)
-	log.SetPrefix(fmt.Sprintf("%s: ", program))
-	log.SetOutput(os.Stderr)
-	flag.Parse()
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run parses the command line arguments, runs the
+// command, and returns the program's exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &pathctl{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	fs := c.flagSet()
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+
+	switch {
+	case c.helpMode:
+		c.usage(fs)
+		return 0
+	case c.versionMode:
+		version.PrintWithCopyright()
+		return 0
+	}
+
+	status, err := c.run(fs.Args())
+	if err != nil {
+		c.logf("%v", err)
+	}
+
+	// Exit like the flag package does on usage errors, so that
+	// they cannot be mistaken for a negative answer from has.
+	if errors.As(err, new(*usageError)) {
+		status = 2
+	}
+
+	return status
+}
+
+// usageError reports that a command was invoked with the wrong arguments.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func usagef(format string, v ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, v...)}
+}
+
+func (c *pathctl) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(program, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() { c.usage(fs) }
+
+	fs.BoolVar(&c.helpMode, "help", false, "display this help and exit.")
+	fs.BoolVar(&c.versionMode, "version", false, "output version information and exit.")
+	fs.BoolVar(&c.dropMode, "D", false, "drop the path before adding it again to the list.")
+	fs.BoolVar(&c.noPrefixMode, "noprefix", false, "output the variable contents only.")
+	fs.BoolVar(&c.verboseMode, "v", false, "report the changes made to the list.")
+	fs.BoolVar(&c.listMode, "L", false, "use a newline character as path list separator.")
+	fs.StringVar(&c.envVar, "E", "PATH", "input environment variable, or - to read the list from standard input.")
+	fs.BoolVar(&c.windowsMode, "windows", runtime.GOOS == "windows", "handle Windows paths and use ';' as path list separator.")
+	fs.Func("S", "override the path list separator.", func(s string) error {
+		if utf8.RuneCountInString(s) != 1 {
+			return fmt.Errorf("invalid separator: %q", s)
+		}
+
+		c.separator, _ = utf8.DecodeRuneInString(s)
+
+		return nil
+	})
+	fs.Func("quote", "quote paths for the shell: none (the default), posix, or always.", func(s string) (err error) {
+		c.quoting, err = parseQuoting(s)
+		return err
+	})
+	fs.BoolVar(&c.stdinMode, "stdin", false, "read the list from standard input, one path per line.")
+	fs.BoolVar(&c.recordMode, "record", true, "record the change in the history.")
+
+	return fs
+}
+
+func parseQuoting(s string) (dirlist.Quoting, error) {
+	switch s {
+	case "none":
+		return dirlist.QuoteNone, nil
+	case "posix":
+		return dirlist.QuotePOSIX, nil
+	case "always":
+		return dirlist.QuoteAlways, nil
+	}
+
+	return dirlist.QuoteNone, fmt.Errorf("invalid quoting policy: %s", s)
+}
+
+func (c *pathctl) run(args []string) (int, error) {
+	dirs, err := c.loadDirList()
+	if err != nil {
+		return 1, err
+	}
+
+	if len(args) < 1 {
+		c.printPathList(dirs)
+		return 0, nil
+	}
+
+	if handler, ok := cmdHandlers[args[0]]; ok {
+		before := dirs.Clone()
+		if err := handler(c, dirs, args[1:]); err != nil {
+			return 1, err
+		}
+
+		if !before.Equal(dirs) && c.recordMode {
+			c.recordHistory(c.envVar, dirs, args)
+		}
+
+		c.printPathList(dirs)
+
+		return 0, nil
+	}
+
+	if handler, ok := queryHandlers[args[0]]; ok {
+		return handler(c, dirs, args[1:])
+	}
 
-	handleHelpAndVersionModes()
+	return 1, usagef("unrecognized command: %s", args[0])
+}
+
+func (c *pathctl) loadDirList() (dirlist.List, error) {
+	dirs := c.newDirList()
+
+	if !c.readFromStdin() {
+		c.rawEntries = c.splitList(os.Getenv(c.envVar))
+		dirs.LoadEnv(c.envVar)
+
+		return dirs, nil
+	}
+
+	// There is no variable name to print.
+	c.noPrefixMode = true
+
+	input, err := io.ReadAll(c.stdin)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read from standard input: %w", err)
+	}
+
+	c.rawEntries = splitInput(string(input))
+	for _, p := range c.rawEntries {
+		if strings.TrimSpace(p) != "" {
+			dirs.Append(p)
+		}
+	}
+
+	return dirs, nil
+}
+
+// newDirList returns an empty list configured according
+// to the command line flags.
+func (c *pathctl) newDirList() dirlist.List {
+	opts := []dirlist.Option{}
+	if c.windowsMode {
+		opts = append(opts, dirlist.WithWindowsPaths())
+	}
+
+	opts = append(opts, dirlist.WithSeparator(c.listSeparator()), dirlist.WithQuoting(c.quoting))
+
+	return dirlist.New(opts...)
+}
+
+func (c *pathctl) listSeparator() rune {
+	switch {
+	case c.separator != 0:
+		return c.separator
+	case c.windowsMode:
+		return ';'
+	default:
+		return filepath.ListSeparator
+	}
+}
 
-	dirs := dirlist.New()
-	dirs.LoadEnv(envVar)
+func (c *pathctl) splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
 
-	if flag.NArg() < 1 {
-		printPathList(dirs)
-		os.Exit(0)
+	return strings.Split(s, string(c.listSeparator()))
+}
+
+// cleanPath cleans p the same way the path list does.
+func (c *pathctl) cleanPath(p string) string {
+	if c.windowsMode {
+		return dirlist.CleanWindowsPath(p)
 	}
 
-	if handler, ok := cmdHandlers[flag.Arg(0)]; ok {
-		handler(dirs)
-		printPathList(dirs)
+	return filepath.Clean(p)
+}
+
+// pathKey returns the form of the clean path p used to compare it
+// with other paths, which ignores case when handling Windows paths.
+func (c *pathctl) pathKey(p string) string {
+	if c.windowsMode {
+		return strings.ToLower(p)
+	}
+
+	return p
+}
+
+func (c *pathctl) readFromStdin() bool {
+	return c.stdinMode || c.envVar == "-"
+}
+
+// splitInput splits s into NUL-separated entries if it contains
+// any NUL character, newline-separated entries otherwise.
+func splitInput(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	sep := "\n"
+	if strings.ContainsRune(s, 0) {
+		sep = "\x00"
 	} else {
-		log.Fatalf("unrecognized command: %s", flag.Arg(0))
+		s = strings.ReplaceAll(s, "\r\n", "\n")
 	}
+
+	return strings.Split(strings.TrimSuffix(s, sep), sep)
 }
 
-func printPathList(d dirlist.List) {
+func (c *pathctl) printPathList(d dirlist.List) {
 	var sb = strings.Builder{}
 	sb.Reset()
 
-	printPrefix := !noPrefixMode
+	printPrefix := !c.noPrefixMode
 
 	switch {
-	case listMode:
+	case c.listMode:
 		sb.WriteString(strings.Join(d.Slice(), "\n"))
 		break
 	case printPrefix:
-		sb.WriteString(fmt.Sprintf("%s=", envVar))
+		sb.WriteString(fmt.Sprintf("%s=", c.envVar))
 		fallthrough
 	default:
 		sb.WriteString(d.String())
 	}
 
-	fmt.Println(sb.String())
+	_, _ = fmt.Fprintln(c.stdout, sb.String())
 }
 
-func handleHelpAndVersionModes() {
-	if !helpMode && !versionMode {
-		return
-	}
-
-	switch {
-	case helpMode:
-		usage()
-	case versionMode:
-		version.PrintWithCopyright()
-	}
-
-	os.Exit(0)
-}
-
-func usage() {
-	s := fmt.Sprintf(`Usage: %s [COMMAND [PATH]]
+func (c *pathctl) usage(fs *flag.FlagSet) {
+	s := fmt.Sprintf(`Usage: %s [COMMAND [PATH...]]
 Make the management of the PATH environment variable
 simple, fast, and predictable.
 
 Commands:
 
-   append, a           append a path to the end of the list.
-   drop, d             drop a path.
-   prepend, p          prepend a path to the list.
+   allow               trust a .pathctl file to be applied by the hook.
+   append, a           append paths to the end of the list.
+   apply               apply a batch of operations to multiple variables.
+   deny                revoke the trust granted by allow.
+   doctor              diagnose common problems in the list.
+   drop, d             drop paths.
+   edit                edit the list interactively.
+   has                 exit with status 0 if the list contains a path,
+                       1 otherwise.
+   history             show the changes made to the list.
+   hook                print the shell hook for bash, fish, or zsh.
+   insert, i           insert a path at a given position.
+   move, m             move a path to a different position.
+   prepend, p          prepend paths to the list.
+   prune               drop nonexistent paths and non-directories.
+   swap                exchange the positions of two paths.
+   undo                revert the change that led to the current list.
+   which               show all the executables with a given name, in
+                       order of priority.
 
 Options:
 `, program)
-	_, _ = fmt.Fprintln(os.Stderr, s)
+	_, _ = fmt.Fprintln(c.stderr, s)
 
-	flag.PrintDefaults()
+	fs.PrintDefaults()
 
-	_, _ = fmt.Fprintln(os.Stderr, `
+	_, _ = fmt.Fprintln(c.stderr, `
 When used with the -D flag, the commands append and prepend
 drop PATH before adding it again to the list. This behaviour
 guarantees that PATH is added as either the first or the last
-element of the path list.
+element of the path list. When multiple paths are given, they
+are added in the order they appear on the command line.
+
+The -windows flag, which is enabled by default on Windows, makes
+pathctl handle drive letters and backslash-separated paths, and
+use ';' as path list separator. Use -S to override the separator.
+
+The drop command accepts the -glob and -prefix options to drop
+all the paths that either match a shell pattern or are located
+under a directory:
+
+   drop -glob PATTERN...
+   drop -prefix DIR...
+
+The insert command accepts either an INDEX (starting from 0) or
+one of -before and -after followed by a path already in the list:
+
+   insert INDEX PATH
+   insert -before EXISTING PATH
+   insert -after EXISTING PATH
+
+If PATH is already in the list, it is moved to the new position.
+
+The move command takes a path already in the list and either
+an INDEX (starting from 0) or one of first, last, up, and down:
+
+   move PATH INDEX
+   move PATH first|last|up|down
+
+The apply command reads a JSON array of operations from FILE,
+or from standard input if FILE is -, and prints an export
+statement for each variable named in the batch:
+
+   [
+     {"var": "PATH", "op": "prepend", "args": ["/opt/local/bin"]},
+     {"var": "MANPATH", "op": "append", "args": ["/opt/local/man"]}
+   ]
+
+Any command that modifies the list can be used as op. The
+current value of each variable is read from the environment.
+Relative paths in FILE are relative to the directory of FILE.
+The paths are quoted as with -quote posix, unless -quote always
+is given.
+
+With -restore, the apply command prints instead the statements
+that restore the current values of the variables in FILE. With
+-allowed, it fails unless the contents of FILE have been trusted
+with the allow command.
+
+The hook command prints a shell hook that applies the operations
+in the .pathctl file of the current directory when the shell enters
+it, and restores the variables when it leaves:
+
+   eval "$(pathctl hook bash)"
+
+The hook applies a .pathctl file only after it has been trusted
+with allow, and again after each change to it. Until the file is
+applied, the hook retries at each prompt, so the file takes effect
+at the prompt that follows allow. The allow and deny commands
+take the file as argument, and default to the .pathctl file of
+the current directory. The changes made by the hook are
+not recorded in the history.
+
+The doctor command does not modify the list. It prints one line
+per problem found and exits with status 1 if there is any.
+
+The edit command is a line-based prompt, not a full-screen
+editor: it displays the list, marking the paths that are not
+existing directories, and reads editing commands, one per line,
+from the standard input. The editor writes to the standard error and, on
+exit, prints the resulting list to the standard output, quoted as
+with -quote posix. Type ? in the editor for the list of commands.
+
+The changes made by the commands that modify the list are recorded
+in the history file in the user's configuration directory, unless
+-record=false is given. The history command lists the changes made
+to the variable. The undo command finds the most recent change that
+led to the current value of the variable, and prints the value the
+variable had before it, quoted as with -quote posix. As undo does
+not modify the history, running undo again after evaluating its
+output reverts the change before.
 
 If COMMAND is not provided, it prints the contents of the PATH
-environment variable.`)
+environment variable. If a command is used incorrectly, pathctl
+exits with status 2.
+
+When used with the -stdin flag or with -E -, the list is read
+from standard input rather than from an environment variable.
+Entries are separated by newlines, or by NUL characters if the
+input contains any, and the output is printed without prefix.`)
+}
+
+func (c *pathctl) cmdHandlerAppend(d dirlist.List, args []string) error {
+	for _, p := range args {
+		if c.dropMode {
+			d.Drop(p)
+		}
+		d.Append(p)
+	}
+
+	return nil
+}
+
+func (c *pathctl) cmdHandlerDrop(d dirlist.List, args []string) error {
+	var match func(pattern, p string) (bool, error)
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	switch args[0] {
+	case "-glob", "--glob":
+		match = c.matchGlob
+	case "-prefix", "--prefix":
+		match = c.matchPrefix
+	default:
+		for _, p := range args {
+			d.Drop(p)
+		}
+
+		return nil
+	}
+
+	for _, pattern := range args[1:] {
+		for _, p := range d.Slice() {
+			ok, err := match(pattern, p)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				d.Drop(p)
+			}
+		}
+	}
+
+	return nil
 }
 
-func cmdHandlerAppend(d dirlist.List) {
-	if dropMode {
-		d.Drop(flag.Arg(1))
+func (c *pathctl) matchGlob(pattern, p string) (bool, error) {
+	match := filepath.Match
+	if c.windowsMode {
+		pattern, p = strings.ReplaceAll(pattern, `\`, "/"), strings.ReplaceAll(p, `\`, "/")
+		match = path.Match
 	}
-	d.Append(flag.Arg(1))
+
+	ok, err := match(pattern, p)
+	if err != nil {
+		return false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	return ok, nil
+}
+
+// matchPrefix returns true if p is either prefix or a path under it.
+func (c *pathctl) matchPrefix(prefix, p string) (bool, error) {
+	sep := string(filepath.Separator)
+	if c.windowsMode {
+		sep = `\`
+	}
+
+	prefix, p = c.pathKey(c.cleanPath(prefix)), c.pathKey(p)
+
+	return p == prefix || strings.HasPrefix(p, strings.TrimSuffix(prefix, sep)+sep), nil
+}
+
+func (c *pathctl) cmdHandlerPrepend(d dirlist.List, args []string) error {
+	// Prepend in reverse order so that the paths
+	// keep the order they were given in.
+	for i := len(args) - 1; i >= 0; i-- {
+		if c.dropMode {
+			d.Drop(args[i])
+		}
+		d.Prepend(args[i])
+	}
+
+	return nil
+}
+
+func (c *pathctl) cmdHandlerInsert(d dirlist.List, args []string) error {
+	var (
+		idx  int
+		path string
+	)
+
+	if len(args) == 0 {
+		return usagef("usage: %s insert INDEX PATH", program)
+	}
+
+	switch args[0] {
+	case "-before", "-after":
+		if len(args) != 3 {
+			return usagef("usage: %s insert %s EXISTING PATH", program, args[0])
+		}
+
+		path = args[2]
+		if c.cleanPath(path) == c.cleanPath(args[1]) {
+			return nil
+		}
+
+		if idx = d.IndexOf(args[1]); idx == -1 {
+			return fmt.Errorf("path not found: %s", args[1])
+		}
+
+		// Account for PATH being dropped before EXISTING.
+		if i := d.IndexOf(path); i != -1 && i < idx {
+			idx--
+		}
+
+		if args[0] == "-after" {
+			idx++
+		}
+	default:
+		if len(args) != 2 {
+			return usagef("usage: %s insert INDEX PATH", program)
+		}
+
+		path = args[1]
+
+		var err error
+		if idx, err = strconv.Atoi(args[0]); err != nil {
+			return fmt.Errorf("invalid index: %s", args[0])
+		}
+	}
+
+	return d.InsertAt(idx, path)
+}
+
+func (c *pathctl) cmdHandlerMove(d dirlist.List, args []string) error {
+	if len(args) != 2 {
+		return usagef("usage: %s move PATH POSITION", program)
+	}
+
+	return movePath(d, args[0], args[1])
+}
+
+// movePath moves path to pos, which is either an
+// index or one of first, last, up, and down.
+func movePath(d dirlist.List, path, pos string) error {
+	from := d.IndexOf(path)
+	if from == -1 {
+		return fmt.Errorf("path not found: %s", path)
+	}
+
+	var to int
+
+	switch pos {
+	case "first":
+		to = 0
+	case "last":
+		to = len(d.Slice()) - 1
+	case "up":
+		to = max(from-1, 0)
+	case "down":
+		to = min(from+1, len(d.Slice())-1)
+	default:
+		var err error
+		if to, err = strconv.Atoi(pos); err != nil {
+			return fmt.Errorf("invalid position: %s", pos)
+		}
+	}
+
+	return d.MoveTo(path, to)
+}
+
+func (c *pathctl) cmdHandlerSwap(d dirlist.List, args []string) error {
+	if len(args) != 2 {
+		return usagef("usage: %s swap PATH1 PATH2", program)
+	}
+
+	return swapPaths(d, args[0], args[1])
+}
+
+func swapPaths(d dirlist.List, p1, p2 string) error {
+	i, j := d.IndexOf(p1), d.IndexOf(p2)
+
+	switch {
+	case i == -1:
+		return fmt.Errorf("path not found: %s", p1)
+	case j == -1:
+		return fmt.Errorf("path not found: %s", p2)
+	case i == j:
+		return nil
+	}
+
+	if i > j {
+		i, j, p1, p2 = j, i, p2, p1
+	}
+
+	// Moving the first path forward shifts the
+	// second one back to where it has to go.
+	if err := d.MoveTo(p1, j); err != nil {
+		return err
+	}
+
+	return d.MoveTo(p2, i)
+}
+
+func (c *pathctl) queryHandlerHas(d dirlist.List, args []string) (int, error) {
+	if len(args) != 1 {
+		return 1, usagef("usage: %s has PATH", program)
+	}
+
+	if d.Contains(args[0]) {
+		return 0, nil
+	}
+
+	return 1, nil
+}
+
+func (c *pathctl) queryHandlerWhich(d dirlist.List, args []string) (int, error) {
+	if len(args) != 1 {
+		return 1, usagef("usage: %s which NAME", program)
+	}
+
+	found, err := d.Which(args[0])
+	if err != nil {
+		return 1, err
+	}
+
+	for _, p := range found {
+		_, _ = fmt.Fprintln(c.stdout, p)
+	}
+
+	return 0, nil
+}
+
+func (c *pathctl) cmdHandlerPrune(d dirlist.List, _ []string) error {
+	for _, p := range d.Slice() {
+		fi, err := os.Stat(p)
+
+		switch {
+		case err != nil:
+			d.Drop(p)
+			c.verbosef("removed %s: %v", p, err)
+		case !fi.IsDir():
+			d.Drop(p)
+			c.verbosef("removed %s: not a directory", p)
+		}
+	}
+
+	return nil
 }
 
-func cmdHandlerDrop(d dirlist.List) {
-	d.Drop(flag.Arg(1))
+// logf writes a message to the standard error,
+// prefixed with the name of the program.
+func (c *pathctl) logf(format string, v ...any) {
+	_, _ = fmt.Fprintf(c.stderr, program+": "+format+"\n", v...)
 }
 
-func cmdHandlerPrepend(d dirlist.List) {
-	if dropMode {
-		d.Drop(flag.Arg(1))
+func (c *pathctl) verbosef(format string, v ...any) {
+	if c.verboseMode {
+		c.logf(format, v...)
 	}
-	d.Prepend(flag.Arg(1))
 }
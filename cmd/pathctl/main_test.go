@@ -0,0 +1,448 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runPathctl runs the program with an isolated configuration
+// directory and returns its exit status and output.
+func runPathctl(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+
+	return status, stdout.String(), stderr.String()
+}
+
+func setConfigDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("AppData", dir)
+
+	return dir
+}
+
+// chdir changes the current directory until the end of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		stdin      string
+		args       []string
+		want       string
+		wantStatus int
+		wantErr    string
+	}{
+		{"print", "/a:/b", "", nil, "PATH=/a:/b\n", 0, ""},
+		{"append", "/a:/b", "", []string{"append", "/c", "/a"}, "PATH=/a:/b:/c\n", 0, ""},
+		{"append drop", "/a:/b", "", []string{"-D", "append", "/a"}, "PATH=/b:/a\n", 0, ""},
+		{"prepend order", "/a", "", []string{"prepend", "/b", "/c"}, "PATH=/b:/c:/a\n", 0, ""},
+		{"insert index", "/a:/b", "", []string{"insert", "1", "/c"}, "PATH=/a:/c:/b\n", 0, ""},
+		{"insert before", "/a:/b:/c:/d", "", []string{"insert", "-before", "/b", "/d"}, "PATH=/a:/d:/b:/c\n", 0, ""},
+		{"insert before moves forward", "/a:/b:/c:/d", "", []string{"insert", "-before", "/c", "/a"}, "PATH=/b:/a:/c:/d\n", 0, ""},
+		{"insert after moves forward", "/a:/b:/c:/d", "", []string{"insert", "-after", "/c", "/a"}, "PATH=/b:/c:/a:/d\n", 0, ""},
+		{"insert after moves back", "/a:/b:/c:/d", "", []string{"insert", "-after", "/a", "/d"}, "PATH=/a:/d:/b:/c\n", 0, ""},
+		{"insert after itself", "/a:/b", "", []string{"insert", "-after", "/a", "/a/"}, "PATH=/a:/b\n", 0, ""},
+		{"insert missing", "/a", "", []string{"insert", "-before", "/x", "/b"}, "", 1, "pathctl: path not found: /x\n"},
+		{"insert bad index", "/a", "", []string{"insert", "x", "/b"}, "", 1, "pathctl: invalid index: x\n"},
+		{"move", "/a:/b:/c", "", []string{"move", "/a", "last"}, "PATH=/b:/c:/a\n", 0, ""},
+		{"move down", "/a:/b:/c", "", []string{"move", "/c", "down"}, "PATH=/a:/b:/c\n", 0, ""},
+		{"swap ends", "/a:/b:/c:/d", "", []string{"swap", "/d", "/a"}, "PATH=/d:/b:/c:/a\n", 0, ""},
+		{"swap adjacent", "/a:/b:/c:/d", "", []string{"swap", "/b", "/c"}, "PATH=/a:/c:/b:/d\n", 0, ""},
+		{"swap missing", "/a:/b", "", []string{"swap", "/a", "/x"}, "", 1, "pathctl: path not found: /x\n"},
+		{"drop", "/a:/b:/c", "", []string{"drop", "/b/", "/x"}, "PATH=/a:/c\n", 0, ""},
+		{"drop glob", "/opt/a/bin:/opt/a/sbin:/usr/bin", "", []string{"drop", "-glob", "/opt/*/bin"}, "PATH=/opt/a/sbin:/usr/bin\n", 0, ""},
+		{"drop bad glob", "/a", "", []string{"drop", "-glob", "["}, "", 1, "pathctl: invalid pattern \"[\": syntax error in pattern\n"},
+		{"drop prefix", "/opt:/opt/bin:/optional/bin:/usr/bin", "", []string{"drop", "-prefix", "/opt/"}, "PATH=/optional/bin:/usr/bin\n", 0, ""},
+		{"stdin newlines", "", "/a\r\n/b c\n\n/a\n", []string{"-stdin"}, "/a:/b c\n", 0, ""},
+		{"stdin nul", "", "/a\x00/b\nc\x00", []string{"-E", "-", "append", "/d"}, "/a:/b\nc:/d\n", 0, ""},
+		{"list mode", "/a:/b", "", []string{"-L"}, "/a\n/b\n", 0, ""},
+		{"quote", "/a b:/c", "", []string{"-noprefix", "-quote", "posix"}, "'/a b':/c\n", 0, ""},
+		{"windows drop prefix", `C:\Tools\bin;c:\tools\sbin;C:\Toolsx`, "", []string{"-windows", "drop", "-prefix", `c:\TOOLS`}, `PATH=C:\Toolsx` + "\n", 0, ""},
+		{"windows doctor", `C:\nonexistent-pathctl;c:\NONEXISTENT-pathctl\`, "", []string{"-windows", "doctor"}, "duplicate: c:\\NONEXISTENT-pathctl\\ (fix: export \"$(pathctl -E PATH)\")\n" +
+			"relative path: C:\\nonexistent-pathctl (fix: use an absolute path)\n" +
+			"nonexistent: C:\\nonexistent-pathctl (fix: export \"$(pathctl -E PATH drop 'C:\\nonexistent-pathctl')\")\n", 1, ""},
+		{"separator", "", "/a\n/b\n", []string{"-stdin", "-S", ","}, "/a,/b\n", 0, ""},
+		{"windows", `c:\a;C:/A/;d:\b\`, "", []string{"-windows"}, `PATH=C:\a;D:\b` + "\n", 0, ""},
+		{"prune", "/:/nonexistent/pathctl:/dev/null", "", []string{"prune"}, "PATH=/\n", 0, ""},
+		{"prune verbose", "/:/nonexistent/pathctl:/dev/null", "", []string{"-v", "prune"}, "PATH=/\n", 0,
+			"pathctl: removed /nonexistent/pathctl: stat /nonexistent/pathctl: no such file or directory\n" +
+				"pathctl: removed /dev/null: not a directory\n"},
+		{"has", "/a:/b", "", []string{"has", "/b/"}, "", 0, ""},
+		{"has not", "/a:/b", "", []string{"has", "/c"}, "", 1, ""},
+		{"has usage", "/a:/b", "", []string{"has"}, "", 2, "pathctl: usage: pathctl has PATH\n"},
+		{"insert usage", "/a", "", []string{"insert", "/b"}, "", 2, "pathctl: usage: pathctl insert INDEX PATH\n"},
+		{"unrecognized", "/a", "", []string{"frob"}, "", 2, "pathctl: unrecognized command: frob\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfigDir(t)
+			t.Setenv("PATH", tt.path)
+
+			status, stdout, stderr := runPathctl(t, tt.stdin, tt.args...)
+			require.Equal(t, tt.wantStatus, status)
+			require.Equal(t, tt.want, stdout)
+			require.Equal(t, tt.wantErr, stderr)
+		})
+	}
+}
+
+func TestRun_Apply(t *testing.T) {
+	setConfigDir(t)
+	t.Setenv("PATH", "/usr/bin:/bin")
+	t.Setenv("MANPATH", "/usr/share/man")
+	t.Setenv("PATHCTL_TEST_UNSET", "")
+	require.NoError(t, os.Unsetenv("PATHCTL_TEST_UNSET"))
+
+	ops := `[
+  {"var": "PATH", "op": "prepend", "args": ["/opt/bin"]},
+  {"var": "MANPATH", "op": "append", "args": ["/opt/man"]},
+  {"var": "PATH", "op": "drop", "args": ["/bin"]},
+  {"var": "PATHCTL_TEST_UNSET", "op": "append", "args": ["/x"]}
+]`
+
+	file := filepath.Join(t.TempDir(), "ops.json")
+	require.NoError(t, os.WriteFile(file, []byte(ops), 0600))
+
+	status, stdout, stderr := runPathctl(t, "", "apply", file)
+	require.Equal(t, 0, status, stderr)
+	require.Equal(t, "export PATH=/opt/bin:/usr/bin\nexport MANPATH=/usr/share/man:/opt/man\nexport PATHCTL_TEST_UNSET=/x\n", stdout)
+
+	status, stdout, _ = runPathctl(t, ops, "apply", "-restore", "-")
+	require.Equal(t, 0, status)
+	require.Equal(t, "export PATH=/usr/bin:/bin\nexport MANPATH=/usr/share/man\nunset PATHCTL_TEST_UNSET\n", stdout)
+
+	t.Setenv("PATH", "/a b:/usr/bin")
+	ops = `[{"var": "PATH", "op": "append", "args": ["/x;rm -rf ~", "/it's"]}]`
+
+	status, stdout, _ = runPathctl(t, ops, "-quote", "none", "apply", "-")
+	require.Equal(t, 0, status)
+	require.Equal(t, `export PATH='/a b':/usr/bin:'/x;rm -rf ~':'/it'\''s'`+"\n", stdout)
+
+	status, stdout, _ = runPathctl(t, ops, "-quote", "always", "apply", "-")
+	require.Equal(t, 0, status)
+	require.Equal(t, `export PATH='/a b':'/usr/bin':'/x;rm -rf ~':'/it'\''s'`+"\n", stdout)
+
+	status, stdout, _ = runPathctl(t, ops, "apply", "-restore", "-")
+	require.Equal(t, 0, status)
+	require.Equal(t, `export PATH='/a b:/usr/bin'`+"\n", stdout)
+
+	status, _, stderr = runPathctl(t, `[{"var": "PATH", "op": "frob"}]`, "apply", "-")
+	require.Equal(t, 1, status)
+	require.Equal(t, "pathctl: operation 0: unrecognized command: frob\n", stderr)
+
+	status, _, stderr = runPathctl(t, `[{"var": "PATH", "op": "swap", "args": ["/x", "/y"]}]`, "apply", "-")
+	require.Equal(t, 1, status)
+	require.Equal(t, "pathctl: operation 0: path not found: /x\n", stderr)
+
+	status, stdout, stderr = runPathctl(t, `[{"var": "X=1; echo PWNED; Y", "op": "append", "args": ["/x"]}]`, "apply", "-")
+	require.Equal(t, 1, status)
+	require.Empty(t, stdout)
+	require.Equal(t, `pathctl: couldn't read operations: operation 0: invalid variable name: "X=1; echo PWNED; Y"`+"\n", stderr)
+
+	status, _, stderr = runPathctl(t, `[{"op": "append", "args": ["/x"]}]`, "apply", "-restore", "-")
+	require.Equal(t, 1, status)
+	require.Equal(t, "pathctl: couldn't read operations: operation 0: missing variable name\n", stderr)
+}
+
+func TestRun_Undo(t *testing.T) {
+	setConfigDir(t)
+	t.Setenv("PATH", "/usr/bin:/bin")
+
+	status, stdout, _ := runPathctl(t, "", "-record=false", "prepend", "/opt/bin")
+	require.Equal(t, 0, status)
+	require.Equal(t, "PATH=/opt/bin:/usr/bin:/bin\n", stdout)
+
+	status, stdout, _ = runPathctl(t, "", "history")
+	require.Equal(t, 0, status)
+	require.Empty(t, stdout)
+
+	status, _, _ = runPathctl(t, "", "prepend", "/opt/bin")
+	require.Equal(t, 0, status)
+
+	t.Setenv("PATH", "/opt/bin:/usr/bin:/bin")
+
+	status, stdout, _ = runPathctl(t, "", "history")
+	require.Equal(t, 0, status)
+	require.Contains(t, stdout, "pathctl prepend /opt/bin\n")
+
+	ops := `[{"var": "PATH", "op": "append", "args": ["/my tools"]}]`
+
+	status, stdout, _ = runPathctl(t, ops, "-record=false", "apply", "-")
+	require.Equal(t, 0, status)
+	require.Equal(t, "export PATH=/opt/bin:/usr/bin:/bin:'/my tools'\n", stdout)
+
+	status, _, _ = runPathctl(t, ops, "-quote", "always", "apply", "-")
+	require.Equal(t, 0, status)
+
+	// The history holds the value the shell
+	// evaluates the output of apply to.
+	t.Setenv("PATH", "/opt/bin:/usr/bin:/bin:/my tools")
+
+	// Previewing does not consume the change.
+	for range 2 {
+		status, stdout, stderr := runPathctl(t, "", "undo")
+		require.Equal(t, 0, status)
+		require.Equal(t, "PATH=/opt/bin:/usr/bin:/bin\n", stdout)
+		require.Empty(t, stderr)
+	}
+
+	t.Setenv("PATH", "/opt/bin:/usr/bin:/bin")
+
+	status, stdout, _ = runPathctl(t, "", "undo")
+	require.Equal(t, 0, status)
+	require.Equal(t, "PATH=/usr/bin:/bin\n", stdout)
+
+	t.Setenv("PATH", "/usr/bin:/bin")
+
+	status, _, stderr := runPathctl(t, "", "undo")
+	require.Equal(t, 1, status)
+	require.Equal(t, "pathctl: no changes to PATH to undo\n", stderr)
+
+	t.Setenv("PATH", "/a b:/usr/bin")
+
+	status, _, _ = runPathctl(t, "", "append", "/x")
+	require.Equal(t, 0, status)
+
+	t.Setenv("PATH", "/a b:/usr/bin:/x")
+
+	status, stdout, _ = runPathctl(t, "", "undo")
+	require.Equal(t, 0, status)
+	require.Equal(t, "PATH='/a b':/usr/bin\n", stdout)
+}
+
+func TestRecordHistory_Concurrent(t *testing.T) {
+	setConfigDir(t)
+	t.Setenv("PATH", "/usr/bin")
+
+	const n = 20
+
+	var wg sync.WaitGroup
+
+	for i := range n {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			var stderr bytes.Buffer
+
+			c := &pathctl{envVar: "PATH", stderr: &stderr}
+			d := c.newDirList()
+			d.Load(fmt.Sprintf("/opt/%d:/usr/bin", i))
+			c.recordHistory("PATH", d, []string{"prepend", fmt.Sprintf("/opt/%d", i)})
+			assert.Empty(t, stderr.String())
+		}()
+	}
+
+	wg.Wait()
+
+	entries, err := loadHistory()
+	require.NoError(t, err)
+	require.Len(t, entries, n)
+}
+
+func TestRun_Edit(t *testing.T) {
+	setConfigDir(t)
+	t.Setenv("PATH", "/a:/b:/c")
+
+	status, stdout, _ := runPathctl(t, "d 0\ns 0 1\na /d\nw\n", "edit")
+	require.Equal(t, 0, status)
+	require.Equal(t, "PATH=/c:/b:/d\n", stdout)
+
+	status, stdout, _ = runPathctl(t, "a  /my tools/bin \n\ti 0\t/opt/a b\np\t/c d\nfoo\nw\n", "edit")
+	require.Equal(t, 0, status)
+	require.Equal(t, "PATH='/c d':'/opt/a b':/a:/b:/c:'/my tools/bin'\n", stdout)
+
+	status, stdout, _ = runPathctl(t, "w\n", "-quote", "always", "edit")
+	require.Equal(t, 0, status)
+	require.Equal(t, "PATH='/a':'/b':'/c'\n", stdout)
+
+	status, stdout, _ = runPathctl(t, "d 0\nx\n", "edit")
+	require.Equal(t, 1, status)
+	require.Empty(t, stdout)
+}
+
+func Test_splitInput(t *testing.T) {
+	require.Nil(t, splitInput(""))
+	require.Equal(t, []string{"/a", "/b"}, splitInput("/a\n/b\n"))
+	require.Equal(t, []string{"/a", "", "/b"}, splitInput("/a\r\n\r\n/b"))
+	require.Equal(t, []string{"/a\n", "/b"}, splitInput("/a\n\x00/b\x00"))
+}
+
+func TestRun_Allow(t *testing.T) {
+	setConfigDir(t)
+	t.Setenv("PATH", "/usr/bin:/bin")
+	chdir(t, t.TempDir())
+
+	dir, err := os.Getwd()
+	require.NoError(t, err)
+
+	ops := `[
+  {"var": "PATH", "op": "prepend", "args": ["bin", "/opt/bin", "../tools"]},
+  {"var": "PATH", "op": "insert", "args": ["-after", "/usr/bin", "sbin"]},
+  {"var": "PATH", "op": "move", "args": ["/opt/bin", "0"]},
+  {"var": "PATH", "op": "drop", "args": ["-prefix", "../tools"]}
+]`
+	require.NoError(t, os.WriteFile(hookFilename, []byte(ops), 0600))
+
+	status, _, stderr := runPathctl(t, "", "apply", "-allowed", hookFilename)
+	require.Equal(t, 1, status)
+	require.Contains(t, stderr, "is not allowed")
+
+	status, _, stderr = runPathctl(t, ops, "apply", "-allowed", "-")
+	require.Equal(t, 1, status)
+	require.Contains(t, stderr, "cannot be allowed")
+
+	status, _, stderr = runPathctl(t, "", "allow")
+	require.Equal(t, 0, status, stderr)
+
+	status, stdout, stderr := runPathctl(t, "", "-record=false", "apply", "-allowed", hookFilename)
+	require.Equal(t, 0, status, stderr)
+	require.Equal(t, fmt.Sprintf("export PATH=/opt/bin:%[1]s/bin:/usr/bin:%[1]s/sbin:/bin\n", dir), stdout)
+
+	status, stdout, _ = runPathctl(t, "", "history")
+	require.Equal(t, 0, status)
+	require.Empty(t, stdout)
+
+	// Files are allowed by their absolute path.
+	require.NoError(t, os.Mkdir("sub", 0700))
+	chdir(t, "sub")
+
+	status, _, stderr = runPathctl(t, "", "apply", "-allowed", filepath.Join("..", hookFilename))
+	require.Equal(t, 0, status, stderr)
+
+	status, _, _ = runPathctl(t, "", "apply", "-allowed", hookFilename)
+	require.Equal(t, 1, status)
+
+	chdir(t, dir)
+
+	require.NoError(t, os.WriteFile(hookFilename, []byte(`[{"var": "PATH", "op": "append", "args": ["x"]}]`), 0600))
+
+	status, _, stderr = runPathctl(t, "", "apply", "-allowed", "-restore", hookFilename)
+	require.Equal(t, 1, status)
+	require.Contains(t, stderr, "changed since it was allowed")
+
+	status, _, _ = runPathctl(t, "", "allow", hookFilename)
+	require.Equal(t, 0, status)
+
+	status, _, _ = runPathctl(t, "", "apply", "-allowed", hookFilename)
+	require.Equal(t, 0, status)
+
+	status, _, _ = runPathctl(t, "", "deny")
+	require.Equal(t, 0, status)
+
+	status, _, stderr = runPathctl(t, "", "apply", "-allowed", hookFilename)
+	require.Equal(t, 1, status)
+	require.Contains(t, stderr, "is not allowed")
+}
+
+func TestRun_Hook(t *testing.T) {
+	for _, shell := range []string{"bash", "fish", "zsh"} {
+		status, stdout, _ := runPathctl(t, "", "hook", shell)
+		require.Equal(t, 0, status)
+		require.Contains(t, stdout, "pathctl apply -allowed -restore .pathctl")
+		require.Contains(t, stdout, "pathctl -record=false apply -allowed .pathctl")
+		require.Contains(t, stdout, "_pathctl_apply 2>/dev/null")
+	}
+
+	status, _, stderr := runPathctl(t, "", "hook", "csh")
+	require.Equal(t, 1, status)
+	require.Equal(t, "pathctl: unsupported shell: csh\n", stderr)
+}
+
+func TestRun_Doctor(t *testing.T) {
+	setConfigDir(t)
+
+	root := t.TempDir()
+	a, b, link := filepath.Join(root, "a"), filepath.Join(root, "b"), filepath.Join(root, "link")
+
+	require.NoError(t, os.Mkdir(a, 0700))
+	require.NoError(t, os.Mkdir(b, 0700))
+	require.NoError(t, os.Symlink(a, link))
+	require.NoError(t, os.WriteFile(filepath.Join(a, "tool"), nil, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(b, "tool"), nil, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(b, "other"), nil, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(b, "data"), nil, 0600))
+
+	t.Setenv("PATH", strings.Join([]string{a, link, b}, ":"))
+
+	status, stdout, _ := runPathctl(t, "", "doctor")
+	require.Equal(t, 1, status)
+	require.Equal(t, fmt.Sprintf(
+		"alias: %[2]s is the same directory as %[1]s (fix: export \"$(pathctl -E PATH drop %[2]s)\")\n"+
+			"shadowed: %[3]s/tool is shadowed by %[1]s/tool\n", a, link, b), stdout)
+
+	t.Setenv("MY_PATH", strings.Join([]string{a, b + "/data", "/no such dir"}, ":"))
+
+	status, stdout, _ = runPathctl(t, "", "-E", "MY_PATH", "doctor")
+	require.Equal(t, 1, status)
+	require.Equal(t, fmt.Sprintf(
+		"not a directory: %[1]s/data (fix: export \"$(pathctl -E MY_PATH drop %[1]s/data)\")\n"+
+			"nonexistent: /no such dir (fix: export \"$(pathctl -E MY_PATH drop '/no such dir')\")\n", b), stdout)
+
+	status, stdout, _ = runPathctl(t, a+"\n"+a+"\n/no such dir\n", "-stdin", "doctor")
+	require.Equal(t, 1, status)
+	require.Equal(t, fmt.Sprintf(
+		"duplicate: %s (fix: remove the entry)\n"+
+			"nonexistent: /no such dir (fix: pathctl -stdin drop '/no such dir')\n", a), stdout)
+
+	t.Setenv("PATH", strings.Join([]string{a, b}, ":"))
+	require.NoError(t, os.Remove(filepath.Join(b, "tool")))
+
+	status, stdout, _ = runPathctl(t, "", "doctor")
+	require.Equal(t, 0, status)
+	require.Empty(t, stdout)
+}
+
+func TestRun_Which(t *testing.T) {
+	setConfigDir(t)
+
+	root := t.TempDir()
+	a, b := filepath.Join(root, "a"), filepath.Join(root, "b")
+
+	require.NoError(t, os.Mkdir(a, 0700))
+	require.NoError(t, os.Mkdir(b, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(a, "tool"), nil, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(b, "tool"), nil, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(b, "data"), nil, 0600))
+
+	t.Setenv("PATH", strings.Join([]string{a, b}, ":"))
+
+	status, stdout, stderr := runPathctl(t, "", "which", "tool")
+	require.Equal(t, 0, status, stderr)
+	require.Equal(t, filepath.Join(a, "tool")+"\n"+filepath.Join(b, "tool")+"\n", stdout)
+
+	status, stdout, stderr = runPathctl(t, "", "which", "data")
+	require.Equal(t, 1, status)
+	require.Empty(t, stdout)
+	require.Equal(t, "pathctl: data: executable file not found in $PATH\n", stderr)
+
+	status, _, _ = runPathctl(t, "", "which")
+	require.Equal(t, 2, status)
+}
@@ -0,0 +1,104 @@
+package dirlist
+
+import (
+	"slices"
+)
+
+// Changes describes the differences between two lists.
+type Changes struct {
+	// Added contains the paths found only in the new list.
+	Added []string
+
+	// Removed contains the paths found only in the old list.
+	Removed []string
+
+	// Moved contains the paths found in both lists whose
+	// position relative to the other common paths changed.
+	Moved []string
+}
+
+// Empty returns true if there are no changes.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Moved) == 0
+}
+
+// diff compares the paths by their key. The changes
+// report the paths as they are spelled in to, except
+// for the removed ones.
+func diff(from, to []string, key func(string) string) (c Changes) {
+	fromKeys, toKeys := keys(from, key), keys(to, key)
+
+	var oldCommon, newCommon, common []string
+
+	for i, k := range fromKeys {
+		if slices.Contains(toKeys, k) {
+			oldCommon = append(oldCommon, k)
+		} else {
+			c.Removed = append(c.Removed, from[i])
+		}
+	}
+
+	for i, k := range toKeys {
+		if slices.Contains(fromKeys, k) {
+			newCommon = append(newCommon, k)
+			common = append(common, to[i])
+		} else {
+			c.Added = append(c.Added, to[i])
+		}
+	}
+
+	// The paths that are not part of the longest common
+	// subsequence are the ones that have been moved.
+	lcs := longestCommonSubsequence(oldCommon, newCommon)
+
+	for i, k := range newCommon {
+		if !slices.Contains(lcs, k) {
+			c.Moved = append(c.Moved, common[i])
+		}
+	}
+
+	return c
+}
+
+func keys(lst []string, key func(string) string) []string {
+	ks := make([]string, len(lst))
+	for i, p := range lst {
+		ks[i] = key(p)
+	}
+
+	return ks
+}
+
+func longestCommonSubsequence(a, b []string) []string {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var lcs []string
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			lcs = append(lcs, a[i])
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return lcs
+}
@@ -0,0 +1,62 @@
+package dirlist
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// expandPath expands the leading "~" or "~user" and
+// the environment variables references in p.
+func expandPath(p string) string {
+	if strings.HasPrefix(p, "~") {
+		name, rest, _ := strings.Cut(p[1:], string(filepath.Separator))
+		if home := homeDir(name); home != "" {
+			p = filepath.Join(home, rest)
+		}
+	}
+
+	return os.ExpandEnv(p)
+}
+
+// homeDir returns the home directory of the user with the given name,
+// or the current user's if name is empty. It returns "" on failure.
+func homeDir(name string) string {
+	if name == "" {
+		home, _ := os.UserHomeDir()
+		return home
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		return ""
+	}
+
+	return u.HomeDir
+}
+
+// contractPaths returns a copy of lst in which the current
+// user's home directory is replaced with "~".
+func contractPaths(lst []string) []string {
+	home := homeDir("")
+	if home == "" || home == string(filepath.Separator) {
+		return lst
+	}
+
+	home = filepath.Clean(home)
+	contracted := make([]string, len(lst))
+
+	for i, p := range lst {
+		switch {
+		case p == home:
+			contracted[i] = "~"
+		case strings.HasPrefix(p, home+string(filepath.Separator)):
+			contracted[i] = "~" + strings.TrimPrefix(p, home)
+		default:
+			contracted[i] = p
+		}
+	}
+
+	return contracted
+}
@@ -3,11 +3,18 @@
 package dirlist
 
 import (
+	"encoding"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"iter"
 	"os"
+	"path"
 	"path/filepath"
 	"slices"
+	"sort"
 	"strings"
+	"unicode"
 )
 
 // List builds a list of directories by parsing PATH-like variables
@@ -24,8 +31,8 @@ type List interface {
 	Load(string)
 
 	// LoadEnv parses the value of an environment variable. It expects
-	// the value to be a string of directories separates by the rune
-	// filepath.ListSeparator.
+	// the value to be a string of directories separated by the list
+	// separator, which defaults to filepath.ListSeparator.
 	LoadEnv(string)
 
 	// Prepend the list with a path.
@@ -37,28 +44,165 @@ type List interface {
 	// Drop remove a path from the list.
 	Drop(string)
 
+	// IndexOf returns the position of a path in the
+	// list, or -1 if the list does not contain it.
+	IndexOf(string) int
+
+	// InsertAt inserts a path at the given position, which must be
+	// in the range [0, n] where n is the length of the list after
+	// the path is dropped from it, if present. It returns the error
+	// of the first validator that rejects the path.
+	InsertAt(int, string) error
+
+	// MoveTo moves a path already in the list to the given position,
+	// which must be in the range [0, n) where n is the length
+	// of the list.
+	MoveTo(string, int) error
+
 	// Slice returns the path list as a slice of strings.
 	Slice() []string
 
+	// All returns an iterator over the paths in the list. The list
+	// must not be modified while iterating, except for the lists
+	// returned by NewSynced, which iterate over a snapshot of the
+	// paths taken when All is called.
+	All() iter.Seq[string]
+
 	// String returns the path list as a string of path list
 	// separator-separated directories.
 	String() string
+
+	// Clone returns an independent copy of the list,
+	// including its options and validators.
+	Clone() List
+
+	// Equal returns true if both lists contain the same paths in
+	// the same order. Paths are compared as the list's options
+	// dictate, e.g. case-insensitively with WithWindowsPaths.
+	Equal(other List) bool
+
+	// Which returns the executables with the given name found in
+	// the directories of the list, in the same order. It returns an
+	// error that wraps exec.ErrNotFound if there is none. On Windows,
+	// a name without any of the extensions listed in PATHEXT is tried
+	// with each of them in turn, as the command interpreter does.
+	Which(name string) ([]string, error)
+
+	// Diff returns the changes needed to turn the list into other.
+	Diff(other List) Changes
+
+	// Sort sorts the list according to less. The sort is stable,
+	// hence paths that compare equal keep their relative order.
+	Sort(less func(a, b string) bool)
+
+	// SetValidators sets the validators that paths must pass
+	// to be added to the list by Load, Append, and Prepend.
+	// It does not affect the paths already in the list.
+	SetValidators(...Validator)
+
+	// Rejected returns the paths that failed validation since
+	// the last call to either Load or Reset.
+	Rejected() []Rejection
+
+	// A list is encoded as an array of paths in JSON, and as a
+	// string of path list separator-separated directories in
+	// text, with neither contraction nor quoting applied. When
+	// decoding JSON, both forms are accepted.
+	// Decoding replaces the contents of the list.
+	json.Marshaler
+	json.Unmarshaler
+	encoding.TextMarshaler
+	encoding.TextUnmarshaler
 }
 
+var (
+	// ErrNotFound is returned when a path is not in the list.
+	ErrNotFound = errors.New("path not found")
+
+	// ErrIndexOutOfRange is returned when a position
+	// is outside of the bounds of the list.
+	ErrIndexOutOfRange = errors.New("index out of range")
+)
+
 type dirList struct {
-	lst []string
-	src string
+	lst        []string
+	src        string
+	sep        rune
+	clean      func(string) string
+	key        func(string) string
+	expand     bool
+	contract   bool
+	quoting    Quoting
+	validators []Validator
+	rejected   []Rejection
+}
+
+// Option configures a List created by New.
+type Option func(*dirList)
+
+// WithSeparator sets the rune that separates the directories
+// in the list. It defaults to filepath.ListSeparator.
+func WithSeparator(sep rune) Option {
+	return func(d *dirList) { d.sep = sep }
+}
+
+// WithWindowsPaths makes the list handle Windows paths regardless
+// of the operating system: directories are separated by ';', cleaned
+// with CleanWindowsPath, and compared case-insensitively. When two
+// paths differ only in case, the list keeps the first one.
+func WithWindowsPaths() Option {
+	return func(d *dirList) {
+		d.sep = ';'
+		d.clean = CleanWindowsPath
+		d.key = strings.ToLower
+	}
+}
+
+// WithExpansion makes the list expand "~", "~user", and environment
+// variables references such as "$VAR" and "${VAR}" in the paths
+// before cleaning them. Paths that expand to an empty string, such
+// as "$UNSET", are ignored rather than cleaned to ".".
+func WithExpansion() Option {
+	return func(d *dirList) { d.expand = true }
+}
+
+// WithContraction makes String replace the current user's home
+// directory with "~" at the beginning of each path.
+func WithContraction() Option {
+	return func(d *dirList) { d.contract = true }
+}
+
+// WithQuoting sets the quoting policy that String applies to
+// each path. It defaults to QuoteNone.
+func WithQuoting(q Quoting) Option {
+	return func(d *dirList) { d.quoting = q }
 }
 
 // New creates a new path list.
-func New() List {
+func New(opts ...Option) List {
 	d := new(dirList)
+	for _, opt := range opts {
+		opt(d)
+	}
+
 	d.init()
+
+	if d.expand {
+		clean := d.clean
+		d.clean = func(p string) string {
+			if p = expandPath(p); strings.TrimSpace(p) == "" {
+				return ""
+			}
+
+			return clean(p)
+		}
+	}
+
 	return d
 }
 
 func (d *dirList) Contains(p string) bool {
-	return slices.Contains(d.lst, filepath.Clean(p))
+	return d.index(d.clean(p)) != -1
 }
 
 func (d *dirList) Reset() {
@@ -67,6 +211,7 @@ func (d *dirList) Reset() {
 
 func (d *dirList) Load(s string) {
 	d.src = s
+	d.rejected = nil
 	d.load()
 }
 
@@ -87,20 +232,125 @@ func (d *dirList) Slice() (dst []string) {
 	panic("couldn't copy the list")
 }
 
+func (d *dirList) All() iter.Seq[string] {
+	return slices.Values(d.lst)
+}
+
 func (d *dirList) String() string {
 	if len(d.lst) == 0 {
 		return ""
 	}
 
-	return strings.Join(d.lst, string(filepath.ListSeparator))
+	lst := d.lst
+	if d.contract {
+		lst = contractPaths(lst)
+	}
+
+	if d.quoting != QuoteNone {
+		lst = quotePaths(lst, d.quoting)
+	}
+
+	return strings.Join(lst, string(d.sep))
+}
+
+func (d *dirList) Clone() List {
+	return d.clone(new(dirList))
+}
+
+func (d *dirList) Equal(other List) bool {
+	return d.equal(other.Slice())
+}
+
+// equal compares the paths as the list's options dictate.
+func (d *dirList) equal(lst []string) bool {
+	return slices.EqualFunc(d.lst, lst, func(a, b string) bool { return d.key(a) == d.key(b) })
+}
+
+func (d *dirList) Which(name string) ([]string, error) {
+	return which(d.lst, name)
+}
+
+func (d *dirList) Diff(other List) Changes {
+	return diff(d.lst, other.Slice(), d.key)
+}
+
+func (d *dirList) MarshalJSON() ([]byte, error) {
+	if len(d.lst) == 0 {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal(d.lst)
+}
+
+func (d *dirList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		d.Load(s)
+		return nil
+	}
+
+	var lst []string
+	if err := json.Unmarshal(data, &lst); err != nil {
+		return fmt.Errorf("couldn't decode path list: %w", err)
+	}
+
+	d.init()
+
+	for _, p := range lst {
+		d.Append(p)
+	}
+
+	return nil
+}
+
+// MarshalText ignores contraction and quoting, as
+// UnmarshalText would not undo them.
+func (d *dirList) MarshalText() ([]byte, error) {
+	return []byte(strings.Join(d.lst, string(d.sep))), nil
+}
+
+func (d *dirList) UnmarshalText(text []byte) error {
+	d.Load(string(text))
+	return nil
+}
+
+func (d *dirList) Sort(less func(a, b string) bool) {
+	sort.SliceStable(d.lst, func(i, j int) bool { return less(d.lst[i], d.lst[j]) })
+}
+
+func (d *dirList) SetValidators(validators ...Validator) {
+	d.validators = validators
+}
+
+func (d *dirList) Rejected() []Rejection {
+	return slices.Clone(d.rejected)
 }
 
 func (d *dirList) load() {
-	d.lst = d.cleanPathVar()
+	d.lst = slices.DeleteFunc(d.cleanPathVar(), func(p string) bool {
+		return d.validate(p) != nil
+	})
+}
+
+// validate runs the validators on p and records it as
+// rejected as soon as one of them fails, returning its error.
+func (d *dirList) validate(p string) error {
+	for _, v := range d.validators {
+		if err := v(p); err != nil {
+			d.rejected = append(d.rejected, Rejection{Path: p, Err: err})
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (d *dirList) Append(path string) {
-	p := filepath.Clean(path)
+	p := d.clean(path)
+	if p == "" || d.validate(p) != nil {
+		return
+	}
+
 	if len(d.lst) == 0 {
 		d.lst = []string{p}
 		return
@@ -116,15 +366,68 @@ func (d *dirList) Drop(path string) {
 		return
 	}
 
-	p := filepath.Clean(path)
+	p := d.clean(path)
 
-	if idx := slices.Index(d.lst, p); idx != -1 {
+	if idx := d.index(p); idx != -1 {
 		d.lst = slices.Delete(d.lst, idx, idx+1)
 	}
 }
 
+func (d *dirList) IndexOf(path string) int {
+	return d.index(d.clean(path))
+}
+
+// index returns the position of the cleaned path p,
+// comparing paths as the list's options dictate.
+func (d *dirList) index(p string) int {
+	k := d.key(p)
+	return slices.IndexFunc(d.lst, func(s string) bool { return d.key(s) == k })
+}
+
+func (d *dirList) InsertAt(i int, path string) error {
+	p := d.clean(path)
+	k := d.key(p)
+	lst := slices.DeleteFunc(slices.Clone(d.lst), func(s string) bool { return d.key(s) == k })
+
+	if i < 0 || i > len(lst) {
+		return fmt.Errorf("%w: %d", ErrIndexOutOfRange, i)
+	}
+
+	if p == "" {
+		return nil
+	}
+
+	if err := d.validate(p); err != nil {
+		return fmt.Errorf("couldn't insert path: %w", err)
+	}
+
+	d.lst = slices.Insert(lst, i, p)
+
+	return nil
+}
+
+func (d *dirList) MoveTo(path string, i int) error {
+	from := d.IndexOf(path)
+	if from == -1 {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
+	if i < 0 || i >= len(d.lst) {
+		return fmt.Errorf("%w: %d", ErrIndexOutOfRange, i)
+	}
+
+	p := d.lst[from]
+	d.lst = slices.Insert(slices.Delete(d.lst, from, from+1), i, p)
+
+	return nil
+}
+
 func (d *dirList) Prepend(path string) {
-	p := filepath.Clean(path)
+	p := d.clean(path)
+	if p == "" || d.validate(p) != nil {
+		return
+	}
+
 	if len(d.lst) == 0 {
 		d.lst = []string{p}
 		return
@@ -138,27 +441,61 @@ func (d *dirList) Prepend(path string) {
 func (d *dirList) init() {
 	d.src = ""
 	d.lst = []string{}
-}
+	d.rejected = nil
 
-func (d *dirList) cleanPathVar() []string {
-	return cleanPathVar(d.src)
+	if d.sep == 0 {
+		d.sep = filepath.ListSeparator
+	}
+
+	if d.clean == nil {
+		d.clean = filepath.Clean
+	}
+
+	if d.key == nil {
+		d.key = func(s string) string { return s }
+	}
 }
 
-func cleanPathVar(src string) []string {
-	if src == "" {
+func (d *dirList) cleanPathVar() []string {
+	if d.src == "" {
 		return nil
 	}
 
-	pthSlice := filepath.SplitList(src)
+	var pthSlice []string
+	if d.sep == filepath.ListSeparator {
+		pthSlice = filepath.SplitList(d.src)
+	} else {
+		pthSlice = strings.Split(d.src, string(d.sep))
+	}
+
 	if len(pthSlice) == 0 {
 		return nil
 	}
 
-	return removeDups(pthSlice, filterEmptyStrings)
+	return removeDups(pthSlice, d.filterEmptyStrings, d.key)
+}
+
+func (d *dirList) filterEmptyStrings(s string) (string, bool) {
+	if _, ok := filterEmptyStrings(s); !ok {
+		return s, false
+	}
+
+	// An expansion may leave nothing to clean.
+	p := d.clean(s)
+
+	return p, p != ""
 }
 
 func (d *dirList) clone(o *dirList) *dirList {
 	o.src = d.src
+	o.sep = d.sep
+	o.clean = d.clean
+	o.key = d.key
+	o.expand = d.expand
+	o.contract = d.contract
+	o.quoting = d.quoting
+	o.validators = slices.Clone(d.validators)
+	o.rejected = slices.Clone(d.rejected)
 
 	n := len(d.lst)
 	o.lst = make([]string, n)
@@ -170,7 +507,7 @@ func (d *dirList) clone(o *dirList) *dirList {
 	return o
 }
 
-func removeDups(col []string, applyFn func(string) (string, bool)) []string {
+func removeDups(col []string, applyFn func(string) (string, bool), key func(string) string) []string {
 	var uniq = make([]string, 0)
 	ks := make(map[string]interface{})
 
@@ -181,9 +518,10 @@ func removeDups(col []string, applyFn func(string) (string, bool)) []string {
 		}
 
 		vv = filepath.Join(filepath.Split(vv))
-		if _, ok := ks[vv]; !ok {
+		k := key(vv)
+		if _, ok := ks[k]; !ok {
 			uniq = append(uniq, vv)
-			ks[vv] = struct{}{}
+			ks[k] = struct{}{}
 		}
 	}
 
@@ -199,3 +537,38 @@ var filterEmptyStrings = func(s string) (string, bool) {
 	// value's nil-ness as it would never be "".
 	return filepath.Clean(s), true
 }
+
+// CleanWindowsPath works like filepath.Clean on Windows regardless
+// of the operating system. Slashes are converted to backslashes, the
+// drive letter, if any, is upper-cased, and the \\server\share prefix
+// of UNC paths is kept as is.
+func CleanWindowsPath(p string) string {
+	var vol string
+
+	if len(p) >= 2 && p[1] == ':' && unicode.IsLetter(rune(p[0])) {
+		vol, p = strings.ToUpper(p[:1])+":", p[2:]
+	}
+
+	p = strings.ReplaceAll(p, `\`, "/")
+
+	if vol == "" && strings.HasPrefix(p, "//") && !strings.HasPrefix(p, "///") {
+		server, rest, _ := strings.Cut(p[2:], "/")
+		share, rest, found := strings.Cut(rest, "/")
+
+		if server != "" && share != "" {
+			vol, p = `\\`+server+`\`+share, ""
+			if found {
+				p = "/" + rest
+			}
+		}
+	}
+
+	switch {
+	case p != "":
+		p = path.Clean(p)
+	case vol == "":
+		p = "."
+	}
+
+	return vol + strings.ReplaceAll(p, "/", `\`)
+}
@@ -1,6 +1,11 @@
 package dirlist
 
 import (
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -31,6 +36,56 @@ func Test_removeDups(t *testing.T) {
 		removeDups(
 			[]string{"alpha", "bravo", "charlie", "bravo", "   ", "."},
 			filterEmptyStrings,
+			func(s string) string { return s },
 		),
 	)
+	require.Equal(t,
+		[]string{"Alpha", "bravo"},
+		removeDups(
+			[]string{"Alpha", "bravo", "alpha", "BRAVO"},
+			filterEmptyStrings,
+			strings.ToLower,
+		),
+	)
+}
+
+func Test_expandPath(t *testing.T) {
+	u, err := user.Current()
+	require.NoError(t, err)
+
+	require.Equal(t, filepath.Join(u.HomeDir, "bin"), expandPath("~"+u.Username+"/bin"))
+	require.Equal(t, "~nonexistent-user-name/bin", expandPath("~nonexistent-user-name/bin"))
+}
+
+func Test_whichOS(t *testing.T) {
+	dirs := []string{t.TempDir(), t.TempDir(), t.TempDir()}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dirs[0], "tool.cmd"), nil, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dirs[0], "tool.exe"), nil, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dirs[1], "tool"), nil, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dirs[2], "tool.ps1"), nil, 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dirs[2], "tool.com"), 0700))
+
+	t.Setenv("PATHEXT", "")
+
+	got, err := whichOS(dirs, "tool", true)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dirs[0], "tool.exe")}, got)
+
+	got, err = whichOS(dirs, "tool.cmd", true)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dirs[0], "tool.cmd")}, got)
+
+	got, err = whichOS(dirs, "tool", false)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dirs[1], "tool")}, got)
+
+	t.Setenv("PATHEXT", ".PS1;CMD")
+
+	got, err = whichOS(dirs, "tool", true)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dirs[0], "tool.cmd"), filepath.Join(dirs[2], "tool.ps1")}, got)
+
+	_, err = whichOS(dirs, "tool.exe", true)
+	require.ErrorIs(t, err, exec.ErrNotFound)
 }
@@ -1,7 +1,13 @@
 package dirlist_test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -59,6 +65,50 @@ func TestList_Drop(t *testing.T) {
 	require.False(t, d1.Contains("/Library/Application Support"))
 }
 
+func TestList_IndexOf(t *testing.T) {
+	d := dirlist.New()
+	require.Equal(t, -1, d.IndexOf("/bin"))
+	d.Load("/usr/bin:/bin")
+	require.Equal(t, 1, d.IndexOf("/bin//"))
+	require.Equal(t, -1, d.IndexOf("/sbin"))
+}
+
+func TestList_InsertAt(t *testing.T) {
+	d := dirlist.New()
+	require.NoError(t, d.InsertAt(0, "/bin"))
+	require.NoError(t, d.InsertAt(1, "/sbin"))
+	require.NoError(t, d.InsertAt(1, "/usr/bin/"))
+	require.Equal(t, "/bin:/usr/bin:/sbin", d.String())
+
+	require.NoError(t, d.InsertAt(2, "/bin"))
+	require.Equal(t, "/usr/bin:/sbin:/bin", d.String())
+
+	require.ErrorIs(t, d.InsertAt(4, "/opt/bin"), dirlist.ErrIndexOutOfRange)
+	require.ErrorIs(t, d.InsertAt(3, "/bin"), dirlist.ErrIndexOutOfRange)
+	require.ErrorIs(t, d.InsertAt(-1, "/opt/bin"), dirlist.ErrIndexOutOfRange)
+	require.Equal(t, "/usr/bin:/sbin:/bin", d.String())
+
+	d.SetValidators(dirlist.MustBeAbsolute)
+	require.ErrorIs(t, d.InsertAt(0, "relative"), dirlist.ErrNotAbs)
+	require.Equal(t, "/usr/bin:/sbin:/bin", d.String())
+	require.Len(t, d.Rejected(), 1)
+}
+
+func TestList_MoveTo(t *testing.T) {
+	d := dirlist.New()
+	d.Load("/a:/b:/c")
+	require.NoError(t, d.MoveTo("/a/", 2))
+	require.Equal(t, "/b:/c:/a", d.String())
+	require.NoError(t, d.MoveTo("/a", 0))
+	require.Equal(t, "/a:/b:/c", d.String())
+	require.NoError(t, d.MoveTo("/c", 1))
+	require.Equal(t, "/a:/c:/b", d.String())
+
+	require.ErrorIs(t, d.MoveTo("/x", 0), dirlist.ErrNotFound)
+	require.ErrorIs(t, d.MoveTo("/a", 3), dirlist.ErrIndexOutOfRange)
+	require.ErrorIs(t, d.MoveTo("/a", -1), dirlist.ErrIndexOutOfRange)
+}
+
 func TestList_Reset(t *testing.T) {
 	d1 := dirlist.New()
 	d1.Reset()
@@ -106,8 +156,436 @@ func TestList_Slice(t *testing.T) {
 	require.Equal(t, []string{"/usr/bin", "/bin"}, d.Slice())
 }
 
+func TestList_All(t *testing.T) {
+	d := dirlist.New()
+	for range d.All() {
+		t.Fatal("unexpected iteration over an empty list")
+	}
+
+	d.Load("/usr/bin:/bin:/sbin")
+
+	var got []string
+	for p := range d.All() {
+		if p == "/sbin" {
+			break
+		}
+
+		got = append(got, p)
+	}
+
+	require.Equal(t, []string{"/usr/bin", "/bin"}, got)
+
+	d = dirlist.NewSynced()
+	d.Load("/usr/bin:/bin:/sbin")
+
+	got = nil
+	for p := range d.All() {
+		d.Drop(p)
+		got = append(got, p)
+	}
+
+	require.Equal(t, []string{"/usr/bin", "/bin", "/sbin"}, got)
+	require.Empty(t, d.Slice())
+}
+
 func TestList_String(t *testing.T) {
 	d := dirlist.New()
 	d.Load("/usr/bin:/bin")
 	require.Equal(t, "/usr/bin:/bin", d.String())
 }
+
+func TestList_WithSeparator(t *testing.T) {
+	d := dirlist.New(dirlist.WithSeparator(','))
+	d.Load("/usr/bin,/bin,,/usr/bin/")
+	require.Equal(t, []string{"/usr/bin", "/bin"}, d.Slice())
+	d.Append("/sbin")
+	require.Equal(t, "/usr/bin,/bin,/sbin", d.String())
+}
+
+func TestList_WithWindowsPaths(t *testing.T) {
+	d := dirlist.New(dirlist.WithWindowsPaths())
+	d.Load(`C:\Windows\system32;c:/Windows/System32/;;C:\Windows\`)
+	require.Equal(t, []string{`C:\Windows\system32`, `C:\Windows`}, d.Slice())
+	require.True(t, d.Contains(`c:\windows`))
+	require.Equal(t, 0, d.IndexOf(`C:\WINDOWS\SYSTEM32`))
+	d.Append(`c:\windows\System32`)
+	require.Equal(t, []string{`C:\Windows\system32`, `C:\Windows`}, d.Slice())
+	require.NoError(t, d.InsertAt(1, `c:\WINDOWS\system32`))
+	require.Equal(t, []string{`C:\Windows`, `C:\WINDOWS\system32`}, d.Slice())
+	d.Drop(`C:/Windows/System32`)
+	d.Prepend(`D:\Tools\bin\`)
+	d.Append(`\\srv\share\bin\`)
+	require.Equal(t, `D:\Tools\bin;C:\Windows;\\srv\share\bin`, d.String())
+
+	for _, newList := range []func(...dirlist.Option) dirlist.List{dirlist.New, dirlist.NewSynced} {
+		d1, d2 := newList(dirlist.WithWindowsPaths()), newList(dirlist.WithWindowsPaths())
+		d1.Load(`C:\Tools;D:\x;E:\y`)
+		d2.Load(`d:\X;c:\TOOLS;F:\z`)
+		require.False(t, d1.Equal(d2))
+		require.Equal(t, dirlist.Changes{
+			Added:   []string{`F:\z`},
+			Removed: []string{`E:\y`},
+			Moved:   []string{`C:\TOOLS`},
+		}, d1.Diff(d2))
+
+		d2.Load(`c:\TOOLS;D:\x;e:\Y`)
+		require.True(t, d1.Equal(d2))
+		require.True(t, d1.Diff(d2).Empty())
+	}
+}
+
+func TestCleanWindowsPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", "."},
+		{`c:`, `C:`},
+		{`c:\`, `C:\`},
+		{`C:\foo\..\bar\\`, `C:\bar`},
+		{`c:/foo/bar/`, `C:\foo\bar`},
+		{`\foo\bar`, `\foo\bar`},
+		{`foo\.\bar`, `foo\bar`},
+		{`\\srv\share`, `\\srv\share`},
+		{`\\srv\share\`, `\\srv\share\`},
+		{`\\srv\share\bin\..\tools\`, `\\srv\share\tools`},
+		{`//srv/share/bin`, `\\srv\share\bin`},
+		{`\\\foo\bar`, `\foo\bar`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			require.Equal(t, tt.want, dirlist.CleanWindowsPath(tt.path))
+		})
+	}
+}
+
+func TestList_SetValidators(t *testing.T) {
+	tmpDir := t.TempDir()
+	regular := filepath.Join(tmpDir, "regular")
+	require.NoError(t, os.WriteFile(regular, nil, 0600))
+
+	d := dirlist.New()
+	d.SetValidators(dirlist.MustBeAbsolute, dirlist.MustBeDir)
+	d.Load(fmt.Sprintf("%s:relative:%s:%s/nonexistent", tmpDir, regular, tmpDir))
+	require.Equal(t, []string{tmpDir}, d.Slice())
+
+	rejected := d.Rejected()
+	require.Len(t, rejected, 3)
+	require.Equal(t, "relative", rejected[0].Path)
+	require.ErrorIs(t, rejected[0].Err, dirlist.ErrNotAbs)
+	require.Equal(t, regular, rejected[1].Path)
+	require.ErrorIs(t, rejected[1].Err, dirlist.ErrNotDir)
+	require.ErrorIs(t, rejected[2].Err, os.ErrNotExist)
+
+	errCustom := errors.New("custom")
+	d.SetValidators(func(p string) error {
+		if p == "/forbidden" {
+			return errCustom
+		}
+
+		return nil
+	})
+	d.Append("/forbidden")
+	d.Prepend("/allowed")
+	require.Equal(t, []string{"/allowed", tmpDir}, d.Slice())
+	require.Len(t, d.Rejected(), 4)
+	require.ErrorIs(t, d.Rejected()[3].Err, errCustom)
+
+	d.Reset()
+	require.Empty(t, d.Rejected())
+}
+
+func TestMustExist(t *testing.T) {
+	require.NoError(t, dirlist.MustExist(t.TempDir()))
+	require.ErrorIs(t, dirlist.MustExist(filepath.Join(t.TempDir(), "nonexistent")), os.ErrNotExist)
+}
+
+func TestList_Sort(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"b", "a"} {
+		require.NoError(t, os.Mkdir(filepath.Join(tmpDir, name), 0700))
+	}
+
+	var (
+		a  = filepath.Join(tmpDir, "a")
+		b  = filepath.Join(tmpDir, "b")
+		nx = filepath.Join(tmpDir, "nonexistent")
+	)
+
+	d := dirlist.New()
+	d.Load(fmt.Sprintf("/z-nonexistent:%s:%s:%s", b, nx, a))
+
+	d.Sort(dirlist.ByExistence)
+	require.Equal(t, []string{b, a, "/z-nonexistent", nx}, d.Slice())
+
+	d.Sort(dirlist.ByExistenceThenName)
+	require.Equal(t, []string{a, b, nx, "/z-nonexistent"}, d.Slice())
+
+	d.Load(fmt.Sprintf("/z-nonexistent:%s:%s:%s", b, nx, a))
+	d.Sort(dirlist.ByName)
+	require.Equal(t, []string{a, b, nx, "/z-nonexistent"}, d.Slice())
+}
+
+func TestList_Clone(t *testing.T) {
+	for _, newFn := range []func(...dirlist.Option) dirlist.List{dirlist.New, dirlist.NewSynced} {
+		d := newFn(dirlist.WithSeparator(','))
+		d.Load("/usr/bin,/bin")
+
+		c := d.Clone()
+		require.True(t, c.Equal(d))
+		require.True(t, d.Equal(c))
+
+		c.Append("/sbin")
+		require.Equal(t, "/usr/bin,/bin,/sbin", c.String())
+		require.Equal(t, "/usr/bin,/bin", d.String())
+		require.False(t, c.Equal(d))
+		require.True(t, d.Equal(d))
+	}
+}
+
+func TestList_Equal(t *testing.T) {
+	d1, d2 := dirlist.New(), dirlist.New()
+	require.True(t, d1.Equal(d2))
+
+	d1.Load("/usr/bin:/bin")
+	d2.Load("/bin:/usr/bin")
+	require.False(t, d1.Equal(d2))
+
+	d2.Load("/usr/bin/:/bin//")
+	require.True(t, d1.Equal(d2))
+}
+
+func TestList_Which(t *testing.T) {
+	var dirs []string
+
+	for _, name := range []string{"a", "b", "c"} {
+		dir := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.Mkdir(dir, 0700))
+		dirs = append(dirs, dir)
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dirs[0], "tool"), nil, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dirs[1], "tool"), nil, 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dirs[1], "other"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dirs[2], "tool"), nil, 0500))
+
+	for _, d := range []dirlist.List{dirlist.New(), dirlist.NewSynced()} {
+		for _, dir := range dirs {
+			d.Append(dir)
+		}
+
+		got, err := d.Which("tool")
+		require.NoError(t, err)
+		require.Equal(t, []string{filepath.Join(dirs[0], "tool"), filepath.Join(dirs[2], "tool")}, got)
+
+		_, err = d.Which("other")
+		require.ErrorIs(t, err, exec.ErrNotFound)
+
+		_, err = d.Which("a/tool")
+		require.Error(t, err)
+	}
+}
+
+func TestList_Diff(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want dirlist.Changes
+	}{
+		{"empty", "", "", dirlist.Changes{}},
+		{"same", "/a:/b", "/a:/b/", dirlist.Changes{}},
+		{"added", "/a", "/b:/a:/c", dirlist.Changes{Added: []string{"/b", "/c"}}},
+		{"removed", "/a:/b:/c", "/b", dirlist.Changes{Removed: []string{"/a", "/c"}}},
+		{"moved", "/a:/b:/c", "/b:/c:/a", dirlist.Changes{Moved: []string{"/a"}}},
+		{"moved many", "/a:/b:/c:/d:/e", "/e:/b:/c:/a:/d", dirlist.Changes{Moved: []string{"/e", "/a"}}},
+		{"all", "/a:/b:/c:/d:/e", "/c:/d:/x:/e:/b", dirlist.Changes{
+			Added:   []string{"/x"},
+			Removed: []string{"/a"},
+			Moved:   []string{"/b"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := dirlist.New(), dirlist.New()
+			from.Load(tt.from)
+			to.Load(tt.to)
+			got := from.Diff(to)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.want.Empty(), got.Empty())
+		})
+	}
+}
+
+func TestList_WithExpansion(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+	t.Setenv("SDK_ROOT", "/opt/sdk")
+
+	d := dirlist.New(dirlist.WithExpansion())
+	d.Load("~/bin:$SDK_ROOT/bin:${SDK_ROOT}/bin:~:/usr/bin:$UNSET_SDK_ROOT/bin")
+	require.Equal(t, []string{"/home/user/bin", "/opt/sdk/bin", "/home/user", "/usr/bin", "/bin"}, d.Slice())
+	require.True(t, d.Contains("~/bin/"))
+
+	d.Drop("$SDK_ROOT/bin")
+	d.Prepend("~/.local/bin")
+	require.Equal(t, "/home/user/.local/bin:/home/user/bin:/home/user:/usr/bin:/bin", d.String())
+
+	d.Load("$UNSET_SDK_ROOT:/usr/bin:${UNSET_SDK_ROOT}")
+	require.Equal(t, []string{"/usr/bin"}, d.Slice())
+
+	d.Load("$UNSET_SDK_ROOT")
+	require.Empty(t, d.Slice())
+
+	d.Append("$UNSET_SDK_ROOT")
+	d.Prepend("$UNSET_SDK_ROOT")
+	require.NoError(t, d.InsertAt(0, "$UNSET_SDK_ROOT"))
+	require.Empty(t, d.Slice())
+
+	d1 := dirlist.New()
+	d1.Load("~/bin:$SDK_ROOT/bin")
+	require.Equal(t, []string{"~/bin", "$SDK_ROOT/bin"}, d1.Slice())
+}
+
+func TestList_WithContraction(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+
+	d := dirlist.New(dirlist.WithExpansion(), dirlist.WithContraction())
+	d.Load("~/bin:/home/user:/home/username/bin:/usr/bin")
+	require.Equal(t, "~/bin:~:/home/username/bin:/usr/bin", d.String())
+	require.Equal(t, []string{"/home/user/bin", "/home/user", "/home/username/bin", "/usr/bin"}, d.Slice())
+}
+
+func TestList_JSON(t *testing.T) {
+	type config struct {
+		Path    dirlist.List
+		ManPath dirlist.List
+	}
+
+	c := config{Path: dirlist.New(), ManPath: dirlist.NewSynced()}
+	c.Path.Load("/usr/bin:/bin")
+
+	data, err := json.Marshal(c)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Path":["/usr/bin","/bin"],"ManPath":[]}`, string(data))
+
+	c1 := config{Path: dirlist.New(), ManPath: dirlist.NewSynced()}
+	require.NoError(t, json.Unmarshal([]byte(`{"Path":["/bin/","/usr/bin","/bin"],"ManPath":"/usr/share/man:/usr/man"}`), &c1))
+	require.Equal(t, []string{"/bin", "/usr/bin"}, c1.Path.Slice())
+	require.Equal(t, []string{"/usr/share/man", "/usr/man"}, c1.ManPath.Slice())
+
+	require.Error(t, json.Unmarshal([]byte(`{"Path":{}}`), &c1))
+}
+
+func TestList_Text(t *testing.T) {
+	d := dirlist.New()
+	require.NoError(t, d.UnmarshalText([]byte("/usr/bin:/bin:/usr/bin")))
+
+	text, err := d.MarshalText()
+	require.NoError(t, err)
+	require.Equal(t, "/usr/bin:/bin", string(text))
+}
+
+func TestList_WithQuoting(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+
+	const src = "/usr/bin:/Library/Application Support/bin:/opt/it's/bin:/home/user/bin"
+
+	tests := []struct {
+		name string
+		opts []dirlist.Option
+		want string
+	}{
+		{"default", nil, src},
+		{"none", []dirlist.Option{dirlist.WithQuoting(dirlist.QuoteNone)}, src},
+		{"posix", []dirlist.Option{dirlist.WithQuoting(dirlist.QuotePOSIX)},
+			`/usr/bin:'/Library/Application Support/bin':'/opt/it'\''s/bin':/home/user/bin`},
+		{"always", []dirlist.Option{dirlist.WithQuoting(dirlist.QuoteAlways)},
+			`'/usr/bin':'/Library/Application Support/bin':'/opt/it'\''s/bin':'/home/user/bin'`},
+		{"always with contraction", []dirlist.Option{dirlist.WithQuoting(dirlist.QuoteAlways), dirlist.WithContraction()},
+			`'/usr/bin':'/Library/Application Support/bin':'/opt/it'\''s/bin':~/'bin'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := dirlist.New(tt.opts...)
+			d.Load(src)
+			require.Equal(t, tt.want, d.String())
+			require.Equal(t, "/Library/Application Support/bin", d.Slice()[1])
+		})
+	}
+}
+
+func TestList_WithQuotingTilde(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+
+	d := dirlist.New(dirlist.WithQuoting(dirlist.QuotePOSIX), dirlist.WithContraction())
+	d.Load("/home/user:/home/user/my tools/bin:/home/user/bin")
+	require.Equal(t, `~:~/'my tools/bin':~/bin`, d.String())
+}
+
+func TestQuoting_Quote(t *testing.T) {
+	require.Equal(t, "/a b", dirlist.QuoteNone.Quote("/a b"))
+	require.Equal(t, "/a", dirlist.QuotePOSIX.Quote("/a"))
+	require.Equal(t, "'/a b'", dirlist.QuotePOSIX.Quote("/a b"))
+	require.Equal(t, `'/a;b'\''c'`, dirlist.QuotePOSIX.Quote("/a;b'c"))
+	require.Equal(t, "'/a'", dirlist.QuoteAlways.Quote("/a"))
+	require.Equal(t, "", dirlist.QuoteAlways.Quote(""))
+}
+
+func TestList_TextRoundTrip(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+
+	opts := []dirlist.Option{
+		dirlist.WithSeparator(','),
+		dirlist.WithQuoting(dirlist.QuotePOSIX),
+		dirlist.WithContraction(),
+	}
+
+	d := dirlist.New(opts...)
+	d.Load("/opt/my tools/bin,/home/user/bin,/usr/bin")
+
+	text, err := d.MarshalText()
+	require.NoError(t, err)
+	require.Equal(t, "/opt/my tools/bin,/home/user/bin,/usr/bin", string(text))
+
+	d1 := dirlist.New(opts...)
+	require.NoError(t, d1.UnmarshalText(text))
+	require.True(t, d.Equal(d1))
+
+	data, err := json.Marshal(d)
+	require.NoError(t, err)
+
+	d2 := dirlist.New(opts...)
+	require.NoError(t, json.Unmarshal(data, d2))
+	require.True(t, d.Equal(d2))
+}
+
+func TestNewSynced(t *testing.T) {
+	d := dirlist.NewSynced()
+	d.Load("/usr/bin:/bin")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			p := fmt.Sprintf("/opt/%d/bin", i)
+			d.Append(p)
+			require.True(t, d.Contains(p))
+			_ = d.String()
+			_ = d.Diff(d)
+
+			for range d.All() {
+				d.Drop("/nonexistent")
+			}
+
+			d.Sort(dirlist.ByName)
+		}()
+	}
+
+	wg.Wait()
+	require.Len(t, d.Slice(), 10)
+	require.True(t, d.Diff(d).Empty())
+}
@@ -0,0 +1,63 @@
+package dirlist
+
+import (
+	"strings"
+)
+
+// Quoting defines how paths are quoted when a list is printed.
+type Quoting int
+
+const (
+	// QuoteNone prints the paths as they are.
+	QuoteNone Quoting = iota
+
+	// QuotePOSIX quotes the paths that contain characters
+	// that are special to POSIX shells, making the output
+	// safe to use in shell assignments.
+	QuotePOSIX
+
+	// QuoteAlways quotes all the paths.
+	QuoteAlways
+)
+
+// Quote returns s quoted according to q, using single
+// quotes so that a POSIX shell reads it verbatim.
+func (q Quoting) Quote(s string) string {
+	if q == QuoteNone || s == "" || (q == QuotePOSIX && !needsQuoting(s)) {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quotePaths returns a copy of lst with each path quoted
+// according to q. A leading "~/" is left unquoted so that
+// the shell can still expand it, as a tilde prefix ends at
+// the first unquoted slash.
+func quotePaths(lst []string, q Quoting) []string {
+	quoted := make([]string, len(lst))
+
+	for i, p := range lst {
+		switch {
+		case p == "~":
+			quoted[i] = p
+		case strings.HasPrefix(p, "~/"):
+			quoted[i] = "~/" + q.Quote(p[2:])
+		default:
+			quoted[i] = q.Quote(p)
+		}
+	}
+
+	return quoted
+}
+
+func needsQuoting(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return false
+		default:
+			return !strings.ContainsRune("/._-+,@%=:", r)
+		}
+	}) != -1
+}
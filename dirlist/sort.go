@@ -0,0 +1,31 @@
+package dirlist
+
+import (
+	"os"
+)
+
+// ByName orders paths lexicographically.
+func ByName(a, b string) bool {
+	return a < b
+}
+
+// ByExistence orders existing directories before the paths
+// that either do not exist or are not directories.
+func ByExistence(a, b string) bool {
+	return isDir(a) && !isDir(b)
+}
+
+// ByExistenceThenName orders existing directories first,
+// and then sorts each of the two groups by name.
+func ByExistenceThenName(a, b string) bool {
+	if x, y := isDir(a), isDir(b); x != y {
+		return x
+	}
+
+	return ByName(a, b)
+}
+
+func isDir(p string) bool {
+	fi, err := os.Stat(p)
+	return err == nil && fi.IsDir()
+}
@@ -0,0 +1,170 @@
+package dirlist
+
+import (
+	"iter"
+	"slices"
+	"sync"
+)
+
+type syncedList struct {
+	mu sync.RWMutex
+	d  *dirList
+}
+
+// NewSynced creates a new path list that is safe for concurrent use
+// by multiple goroutines. The functions passed to Sort and to
+// SetValidators are called with the list locked, hence they must
+// not call the list's methods.
+func NewSynced(opts ...Option) List {
+	return &syncedList{d: New(opts...).(*dirList)}
+}
+
+func (s *syncedList) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.d.Reset()
+}
+
+func (s *syncedList) Contains(p string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.d.Contains(p)
+}
+
+func (s *syncedList) Load(src string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.d.Load(src)
+}
+
+func (s *syncedList) LoadEnv(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.d.LoadEnv(name)
+}
+
+func (s *syncedList) Prepend(p string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.d.Prepend(p)
+}
+
+func (s *syncedList) Append(p string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.d.Append(p)
+}
+
+func (s *syncedList) Drop(p string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.d.Drop(p)
+}
+
+func (s *syncedList) IndexOf(p string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.d.IndexOf(p)
+}
+
+func (s *syncedList) InsertAt(i int, p string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.InsertAt(i, p)
+}
+
+func (s *syncedList) MoveTo(p string, i int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.MoveTo(p, i)
+}
+
+func (s *syncedList) Slice() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.d.Slice()
+}
+
+// All iterates over a snapshot of the list taken when
+// it is called, so the list can be modified meanwhile.
+func (s *syncedList) All() iter.Seq[string] {
+	return slices.Values(s.Slice())
+}
+
+func (s *syncedList) String() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.d.String()
+}
+
+func (s *syncedList) Clone() List {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &syncedList{d: s.d.clone(new(dirList))}
+}
+
+func (s *syncedList) Equal(other List) bool {
+	// Read other first as it may be s itself.
+	lst := other.Slice()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.d.equal(lst)
+}
+
+func (s *syncedList) Which(name string) ([]string, error) {
+	return which(s.Slice(), name)
+}
+
+func (s *syncedList) Diff(other List) Changes {
+	// Read other first as it may be s itself.
+	to := other.Slice()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return diff(s.d.lst, to, s.d.key)
+}
+
+func (s *syncedList) Sort(less func(a, b string) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.d.Sort(less)
+}
+
+func (s *syncedList) SetValidators(validators ...Validator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.d.SetValidators(validators...)
+}
+
+func (s *syncedList) Rejected() []Rejection {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.d.Rejected()
+}
+
+func (s *syncedList) MarshalJSON() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.d.MarshalJSON()
+}
+
+func (s *syncedList) UnmarshalJSON(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.UnmarshalJSON(data)
+}
+
+func (s *syncedList) MarshalText() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.d.MarshalText()
+}
+
+func (s *syncedList) UnmarshalText(text []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.UnmarshalText(text)
+}
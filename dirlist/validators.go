@@ -0,0 +1,57 @@
+package dirlist
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrNotDir is returned by MustBeDir when a path
+	// exists but is not a directory.
+	ErrNotDir = errors.New("not a directory")
+
+	// ErrNotAbs is returned by MustBeAbsolute when
+	// a path is relative.
+	ErrNotAbs = errors.New("not an absolute path")
+)
+
+// Validator checks whether a path is allowed in a list.
+// It returns a non-nil error to reject the path.
+type Validator func(string) error
+
+// Rejection records a path that failed validation.
+type Rejection struct {
+	Path string
+	Err  error
+}
+
+// MustExist rejects paths that do not exist.
+func MustExist(p string) error {
+	_, err := os.Stat(p)
+	return err
+}
+
+// MustBeDir rejects paths that do not exist or are not directories.
+func MustBeDir(p string) error {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+
+	if !fi.IsDir() {
+		return fmt.Errorf("%s: %w", p, ErrNotDir)
+	}
+
+	return nil
+}
+
+// MustBeAbsolute rejects relative paths.
+func MustBeAbsolute(p string) error {
+	if !filepath.IsAbs(p) {
+		return fmt.Errorf("%s: %w", p, ErrNotAbs)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package dirlist
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+func which(dirs []string, name string) ([]string, error) {
+	return whichOS(dirs, name, runtime.GOOS == "windows")
+}
+
+// whichOS implements which, following the rules of Windows if windows
+// is true: names without one of the extensions listed in PATHEXT are
+// tried with each of them, and any regular file is executable.
+func whichOS(dirs []string, name string, windows bool) ([]string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid executable name: %q", name)
+	}
+
+	names := []string{name}
+	if exts := pathExts(); windows && !slices.Contains(exts, strings.ToLower(filepath.Ext(name))) {
+		names = names[:0]
+		for _, ext := range exts {
+			names = append(names, name+ext)
+		}
+	}
+
+	var found []string
+
+	for _, dir := range dirs {
+		// Only the first match in each directory
+		// can be run by its name.
+		for _, name := range names {
+			if p := filepath.Join(dir, name); isExecutable(p, windows) {
+				found = append(found, p)
+				break
+			}
+		}
+	}
+
+	if len(found) == 0 {
+		return nil, fmt.Errorf("%s: %w", name, exec.ErrNotFound)
+	}
+
+	return found, nil
+}
+
+// pathExts returns the lower-cased extensions listed in PATHEXT,
+// or those that Windows uses by default if it is not set.
+func pathExts() []string {
+	var exts []string
+
+	for _, ext := range strings.Split(strings.ToLower(os.Getenv("PATHEXT")), ";") {
+		if ext == "" {
+			continue
+		}
+
+		if ext[0] != '.' {
+			ext = "." + ext
+		}
+
+		exts = append(exts, ext)
+	}
+
+	if len(exts) == 0 {
+		return []string{".com", ".exe", ".bat", ".cmd"}
+	}
+
+	return exts
+}
+
+func isExecutable(p string, windows bool) bool {
+	fi, err := os.Stat(p)
+	if err != nil || !fi.Mode().IsRegular() {
+		return false
+	}
+
+	return windows || fi.Mode().Perm()&0o111 != 0
+}
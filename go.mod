@@ -1,6 +1,6 @@
 module al.essio.dev/pkg/tools
 
-go 1.21
+go 1.23
 
 require github.com/stretchr/testify v1.10.0
 